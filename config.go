@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// config holds defaults read from the config file. Flags override these,
+// and these override the built-in defaults.
+type config struct {
+	Agent     string `json:"agent"`
+	Tail      int    `json:"tail"`
+	Color     string `json:"color"`
+	Theme     string `json:"theme"`
+	MaxText   int    `json:"max_text"`
+	MaxResult int    `json:"max_result"`
+	MaxArgs   int    `json:"max_args"`
+	// Colors overrides role colors, e.g. {"assistant": "blue"}
+	Colors colorConfig `json:"colors"`
+}
+
+// colorConfig holds the per-role color overrides of the config file
+type colorConfig struct {
+	User      string `json:"user"`
+	Assistant string `json:"assistant"`
+	Thinking  string `json:"thinking"`
+	Tool      string `json:"tool"`
+	System    string `json:"system"`
+}
+
+// forRole returns the override for one of colorRoles, or ""
+func (c colorConfig) forRole(role string) string {
+	switch role {
+	case "user":
+		return c.User
+	case "assistant":
+		return c.Assistant
+	case "thinking":
+		return c.Thinking
+	case "tool":
+		return c.Tool
+	case "system":
+		return c.System
+	}
+	return ""
+}
+
+// defaultConfig returns the built-in defaults
+func defaultConfig() config {
+	return config{
+		Agent:     defaultAgent,
+		Tail:      defaultTail,
+		Color:     colorAuto,
+		Theme:     defaultTheme,
+		MaxText:   maxTextLen,
+		MaxResult: maxResultLen,
+		MaxArgs:   maxArgLen,
+	}
+}
+
+// configPath returns ~/.config/session-stream/config.json, or the
+// platform's equivalent user config directory
+func configPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "session-stream", "config.json")
+}
+
+// lastSessionPath returns the file recording the last streamed session for
+// --resume, next to the config file
+func lastSessionPath() string {
+	path := configPath()
+	if path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(path), "last-session")
+}
+
+// Global file to record streamed sessions in; empty disables it
+var lastSessionFile string
+
+// saveLastSession records path as the session --resume reopens. It's best
+// effort: a read-only config directory just means nothing to resume.
+func saveLastSession(path string) {
+	if lastSessionFile == "" {
+		return
+	}
+	if !isURL(path) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(lastSessionFile), 0o755); err != nil {
+		return
+	}
+	os.WriteFile(lastSessionFile, []byte(path+"\n"), 0o644)
+}
+
+// loadLastSession returns the session recorded by saveLastSession
+func loadLastSession() (string, error) {
+	data, err := os.ReadFile(lastSessionFile)
+	if errors.Is(err, fs.ErrNotExist) || err == nil && strings.TrimSpace(string(data)) == "" {
+		return "", fmt.Errorf("no session to resume yet")
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// loadConfig reads the config file at path over the built-in defaults. A
+// missing file is not an error; keys it leaves out keep their defaults.
+func loadConfig(path string) (config, error) {
+	cfg := defaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return defaultConfig(), err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Global flag: reduce every message to a single digest line
+var summaryOnlyMode bool
+
+// Default width of a digest line when the terminal width is unknown
+const digestWidth = 100
+
+// processDigestLine renders a JSONL line as one "10:30:01 assistant: Let me
+// check the config…" line, applying the same filters and tool pairing as
+// processLine
+func processDigestLine(line string) (result ProcessedLine) {
+	e, done, ok := parseLine(line, nil)
+	if !ok {
+		return done
+	}
+	defer e.annotate(&result)
+	entry, role, content, usage, clock := &e.entry, e.role, e.content, e.usage, e.clock
+
+	var preview string
+	switch role {
+	case "request":
+		if !verboseMode {
+			return ProcessedLine{Skipped: skipRequest}
+		}
+		preview = requestSummary(entry.Request)
+
+	case "tool_call":
+		toolPairs.start(entry.ToolID, entry.ToolName, e.time)
+		name := entry.ToolName
+		if name == "" {
+			name = "?"
+		}
+		if !toolAllowed(name) {
+			return ProcessedLine{Skipped: skipFiltered}
+		}
+		preview = fmt.Sprintf("%s(%s)", name, summarizeArgs(entry.ToolInput))
+
+	case "tool_result":
+		call, _ := toolPairs.finish(entry.ToolID)
+		name := entry.ToolName
+		if name == "" {
+			name = call.name
+		}
+		if !toolAllowed(name) {
+			return ProcessedLine{Skipped: skipFiltered}
+		}
+		preview = "→ " + extractText(content)
+		if entry.IsError {
+			preview = "✗ " + extractText(content)
+		}
+
+	case "tool":
+		for _, block := range contentBlocks(content, "toolCall") {
+			toolPairs.start(blockID(block), toolCallName(block), e.time)
+		}
+		var parts []string
+		results := contentBlocks(content, "toolResult")
+		for _, block := range results {
+			call, _ := toolPairs.finish(blockID(block))
+			name, _ := block["name"].(string)
+			if name == "" {
+				name = call.name
+			}
+			if toolAllowed(name) {
+				parts = append(parts, toolResultText(block))
+			}
+		}
+		if len(results) == 0 {
+			// Plain-text result: pair it with the oldest pending call
+			call, _ := toolPairs.finish("")
+			if !toolAllowed(call.name) {
+				return ProcessedLine{Skipped: skipFiltered}
+			}
+			parts = append(parts, extractText(content))
+		} else if len(parts) == 0 {
+			return ProcessedLine{Skipped: skipFiltered}
+		}
+		preview = "→ " + strings.Join(parts, " | ")
+
+	case "user":
+		toolPairs.reset()
+		preview = extractText(content)
+		if reason := hideReason(preview); reason != "" {
+			return ProcessedLine{Skipped: reason}
+		}
+
+	case "assistant":
+		preview = extractText(content)
+		var calls []string
+		for _, block := range contentBlocks(content, "toolCall") {
+			name := toolCallName(block)
+			toolPairs.start(blockID(block), name, e.time)
+			if toolAllowed(name) {
+				calls = append(calls, name)
+			}
+		}
+		if len(calls) > 0 {
+			preview = strings.TrimSpace(preview + " ⚡ " + strings.Join(calls, ", "))
+		}
+
+	case "thinking", "system":
+		preview = extractText(content)
+	}
+
+	preview = strings.Join(strings.Fields(preview), " ")
+	if preview == "" || preview == "→" {
+		return ProcessedLine{Usage: usage}
+	}
+
+	prefix := role + ": "
+	if clock != "" {
+		prefix = clock + " " + prefix
+	}
+	width := wrapWidth
+	if width <= 0 {
+		width = digestWidth
+	}
+	preview = truncate(preview, max(width-len(prefix), 10))
+
+	if clock != "" {
+		clock = dim + clock + reset + " "
+	}
+	return ProcessedLine{
+		Output: fmt.Sprintf("%s%s%s:%s %s", clock, roleColor(role), role, reset, preview),
+		Usage:  usage,
+	}
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// docRenderer renders normalized session entries for a document export
+// format. processDocLine handles parsing and filtering; implementations only
+// decide how each piece looks, redacting the text they're given but never
+// their own markup.
+type docRenderer interface {
+	// begin and end wrap the whole document
+	begin(name string) string
+	end() string
+	// message renders a "user", "assistant", or "thinking" header with
+	// optional usage and body text
+	message(role, ts, usage, text string) string
+	toolCall(name string, args map[string]interface{}) string
+	// toolResult renders a result; details is a short "2 lines, 1.2s" note
+	toolResult(name, text string, isError bool, details string) string
+	// note renders a one-line entry such as a system message
+	note(label, ts, text string) string
+	// group joins the blocks rendered for a single entry
+	group(blocks []string) string
+	summary(t *sessionTotals) string
+}
+
+// docRendererFor returns the renderer for a document output format, or nil
+// for the default terminal format
+func docRendererFor(format string) docRenderer {
+	switch format {
+	case formatMarkdown:
+		return markdownRenderer{}
+	case formatHTML:
+		return htmlRenderer{}
+	}
+	return nil
+}
+
+// processDocLine renders a JSONL line with a document renderer, reusing the
+// same normalization, filtering, and tool pairing as processLine
+func processDocLine(line string, r docRenderer) (result ProcessedLine) {
+	e, done, ok := parseLine(line, func(clock string) string { return r.note("tick", clock, "") })
+	if !ok {
+		return done
+	}
+	defer e.annotate(&result)
+	entry, role, content, usage, tsTime, ts := &e.entry, e.role, e.content, e.usage, e.time, e.clock
+
+	switch role {
+	case "request":
+		if verboseMode {
+			return ProcessedLine{Output: r.note("request", ts, requestSummary(entry.Request))}
+		}
+		return ProcessedLine{Skipped: skipRequest}
+
+	case "thinking":
+		text := extractText(content)
+		if text != "" {
+			if !fullThinkingMode {
+				text = docPreview(text)
+			}
+			return ProcessedLine{Output: r.message("thinking", ts, "", text)}
+		}
+
+	case "tool_call":
+		toolPairs.start(entry.ToolID, entry.ToolName, tsTime)
+		name := entry.ToolName
+		if name == "" {
+			name = "?"
+		}
+		if !toolAllowed(name) {
+			return ProcessedLine{Skipped: skipFiltered}
+		}
+		return ProcessedLine{Output: r.group([]string{r.toolCall(name, entry.ToolInput)})}
+
+	case "tool_result":
+		call, _ := toolPairs.finish(entry.ToolID)
+		name := entry.ToolName
+		if name == "" {
+			name = call.name
+		}
+		if !toolAllowed(name) {
+			return ProcessedLine{Skipped: skipFiltered}
+		}
+		text := extractText(content)
+		if text == "" {
+			return ProcessedLine{}
+		}
+		return ProcessedLine{Output: r.group([]string{docResult(r, name, text, entry.IsError, call, tsTime)})}
+
+	case "user":
+		toolPairs.reset()
+		text := extractText(content)
+		if reason := hideReason(text); reason != "" {
+			return ProcessedLine{Skipped: reason}
+		}
+		if text != "" {
+			return ProcessedLine{Output: r.message("user", ts, "", docPreview(text))}
+		}
+
+	case "assistant":
+		var blocks []string
+		text := extractText(content)
+		for _, block := range contentBlocks(content, "toolCall") {
+			name := toolCallName(block)
+			toolPairs.start(blockID(block), name, tsTime)
+			if !toolAllowed(name) {
+				continue
+			}
+			args, _ := block["arguments"].(map[string]interface{})
+			blocks = append(blocks, r.toolCall(name, args))
+		}
+		if strings.TrimSpace(text) == "" && len(blocks) == 0 {
+			if len(contentBlocks(content, "toolCall")) > 0 {
+				// Every call was filtered out, but the turn still cost tokens
+				return ProcessedLine{Usage: usage, Skipped: skipFiltered}
+			}
+			return ProcessedLine{}
+		}
+		if strings.TrimSpace(text) == "" {
+			text = ""
+		}
+		detail := strings.TrimSpace(modelLabel(entryModel(entry)) + prefixSpace(usageText(usage)))
+		blocks = append([]string{r.message("assistant", ts, detail, text)}, blocks...)
+		return ProcessedLine{
+			Output: r.group(blocks),
+			Usage:  usage,
+		}
+
+	case "tool":
+		var blocks []string
+		for _, block := range contentBlocks(content, "toolCall") {
+			toolPairs.start(blockID(block), toolCallName(block), tsTime)
+		}
+		for _, block := range contentBlocks(content, "toolResult") {
+			call, _ := toolPairs.finish(blockID(block))
+			name, _ := block["name"].(string)
+			if name == "" {
+				name = call.name
+			}
+			if !toolAllowed(name) {
+				continue
+			}
+			if text := toolResultText(block); strings.TrimSpace(text) != "" {
+				blocks = append(blocks, docResult(r, name, text, blockIsError(block), call, tsTime))
+			}
+		}
+		if len(blocks) == 0 {
+			text := extractText(content)
+			if strings.TrimSpace(text) == "" {
+				return ProcessedLine{}
+			}
+			call, _ := toolPairs.finish("")
+			if !toolAllowed(call.name) {
+				return ProcessedLine{Skipped: skipFiltered}
+			}
+			blocks = append(blocks, docResult(r, call.name, text, false, call, tsTime))
+		}
+		return ProcessedLine{Output: r.group(blocks)}
+
+	case "system":
+		text := extractText(content)
+		if strings.TrimSpace(text) != "" {
+			return ProcessedLine{Output: r.note("system", ts, truncate(text, maxSystemLen))}
+		}
+	}
+
+	return ProcessedLine{}
+}
+
+// docPreview shortens long text like previewText, without ANSI codes
+func docPreview(text string) string {
+	if fullMode || maxTextLen <= 0 || len(text) <= maxTextLen {
+		return text
+	}
+	return cutString(text, maxTextLen*2/5) + fmt.Sprintf("\n\n… (%d bytes)", len(text))
+}
+
+// docResult renders a tool result with its line count and call duration
+func docResult(r docRenderer, name, text string, isError bool, call pendingToolCall, at time.Time) string {
+	details := fmt.Sprintf("%d lines", strings.Count(text, "\n")+1)
+	if d, ok := call.elapsed(at); ok {
+		details += ", " + formatElapsed(d)
+	}
+	if indented, ok := indentJSON(text); ok && prettyResultsMode {
+		text = indented
+	}
+	return r.toolResult(name, truncate(text, maxResultLen), isError, details)
+}
+
+// prettyJSON renders tool arguments as indented JSON
+func prettyJSON(args map[string]interface{}) string {
+	if len(args) == 0 {
+		return "{}"
+	}
+	data, err := json.MarshalIndent(args, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", args)
+	}
+	return string(data)
+}
+
+// roleTitles are the display names for message roles in exports
+var roleTitles = map[string]string{
+	"user":      "👤 You",
+	"assistant": "🤖 Agent",
+	"thinking":  "💭 Thinking",
+}
+
+// markdownRenderer renders a session as markdown for sharing: messages
+// become headers with blockquoted text, tool calls become fenced code blocks
+// labeled with the tool name, and results collapse into <details> blocks.
+type markdownRenderer struct{}
+
+func (markdownRenderer) begin(name string) string {
+	return fmt.Sprintf("# Session: %s\n\n", name)
+}
+
+func (markdownRenderer) end() string {
+	return ""
+}
+
+func (markdownRenderer) message(role, ts, usage, text string) string {
+	header := "### " + roleTitles[role]
+	if ts != "" {
+		header += fmt.Sprintf(" _%s_", ts)
+	}
+	if usage != "" {
+		header += fmt.Sprintf(" · `%s`", usage)
+	}
+	if text == "" {
+		return header + "\n"
+	}
+	return header + "\n\n" + markdownQuote(redact(text)) + "\n"
+}
+
+func (markdownRenderer) toolCall(name string, args map[string]interface{}) string {
+	return markdownFence(name, redact(prettyJSON(args)))
+}
+
+func (markdownRenderer) toolResult(name, text string, isError bool, details string) string {
+	label := "Result"
+	if isError {
+		label = "✗ Error"
+	}
+	if name != "" {
+		label = name + " " + strings.ToLower(label)
+	}
+	return fmt.Sprintf("<details>\n<summary>%s (%s)</summary>\n\n%s\n\n</details>", label, details, markdownFence("", redact(text)))
+}
+
+func (markdownRenderer) note(label, ts, text string) string {
+	line := fmt.Sprintf("_[%s]_", label)
+	if ts != "" {
+		line += fmt.Sprintf(" _%s_", ts)
+	}
+	if text != "" {
+		line += " " + redact(text)
+	}
+	return line + "\n"
+}
+
+func (markdownRenderer) group(blocks []string) string {
+	for i, block := range blocks {
+		blocks[i] = strings.TrimRight(block, "\n")
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+func (markdownRenderer) summary(t *sessionTotals) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\n\n**Total:** %s\n", t)
+	if byModelMode && len(t.ByModel) > 0 {
+		b.WriteString("\n| model | msgs | tokens | out | cost |\n|---|---:|---:|---:|---:|\n")
+		for _, name := range t.sortedModels() {
+			m := t.ByModel[name]
+			fmt.Fprintf(&b, "| %s | %d | %s | %s | %s |\n", name, m.Messages, formatNumber(m.Tokens), formatNumber(m.Output), approxCost(m.Cost, m.Estimated))
+		}
+	}
+	return b.String()
+}
+
+// markdownQuote renders text as a markdown blockquote
+func markdownQuote(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownFence wraps body in a fenced code block, using a fence longer than
+// any backtick run inside body so the block can't be closed early
+func markdownFence(lang, body string) string {
+	longest := 0
+	run := 0
+	for _, r := range body {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fmt.Sprintf("%s%s\n%s\n%s", fence, lang, strings.TrimRight(body, "\n"), fence)
+}
+
+// htmlRenderer renders a session as a self-contained HTML page whose CSS
+// mirrors the terminal colors. Each message is a div with a role class.
+type htmlRenderer struct{}
+
+// htmlStyle mirrors the terminal palette: cyan user, green assistant,
+// magenta tool calls, dim results, blue system notes
+const htmlStyle = `body { background: #1e1e1e; color: #d4d4d4; font-family: ui-monospace, Menlo, Consolas, monospace; font-size: 14px; max-width: 960px; margin: 2em auto; padding: 0 1em; }
+h1 { color: #e5c07b; font-size: 1.2em; border-bottom: 1px solid #444; padding-bottom: .5em; }
+pre { white-space: pre-wrap; word-wrap: break-word; margin: .25em 0 .25em 1.5em; }
+.message { margin-top: 1.2em; }
+.header { font-weight: bold; }
+.meta { color: #888; font-weight: normal; }
+.user { color: #56b6c2; }
+.assistant { color: #98c379; }
+.thinking { color: #e5c07b; }
+.thinking pre { color: #999; }
+.tool-call { color: #c678dd; margin-left: 1.5em; }
+.tool-call pre { color: #888; }
+.tool-result { color: #888; margin-left: 1.5em; }
+.tool-result.error { color: #e06c75; }
+.note { color: #61afef; opacity: .8; margin-top: 1.2em; }
+.summary { color: #888; border-top: 1px solid #444; margin-top: 2em; padding-top: .5em; }
+table { border-collapse: collapse; margin-top: .5em; }
+td, th { padding: 0 1em 0 0; text-align: right; }
+td:first-child, th:first-child { text-align: left; color: #56b6c2; }
+`
+
+func (htmlRenderer) begin(name string) string {
+	title := html.EscapeString(name)
+	return fmt.Sprintf("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s</style>\n</head>\n<body>\n<h1>Session: %s</h1>\n", title, htmlStyle, title)
+}
+
+func (htmlRenderer) end() string {
+	return "</body>\n</html>\n"
+}
+
+// htmlText escapes message text for HTML. Redaction has to happen first:
+// once quotes become &#34; the credential rules no longer match.
+func htmlText(text string) string {
+	return html.EscapeString(redact(text))
+}
+
+func (htmlRenderer) message(role, ts, usage, text string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<div class=\"message %s\">\n<div class=\"header\">━━━ %s", role, html.EscapeString(roleTitles[role]))
+	if ts != "" {
+		fmt.Fprintf(&b, " <span class=\"meta\">%s</span>", html.EscapeString(ts))
+	}
+	if usage != "" {
+		fmt.Fprintf(&b, " <span class=\"meta\">%s</span>", html.EscapeString(usage))
+	}
+	b.WriteString(" ━━━</div>\n")
+	if text != "" {
+		fmt.Fprintf(&b, "<pre>%s</pre>\n", htmlText(text))
+	}
+	b.WriteString("</div>")
+	return b.String()
+}
+
+func (htmlRenderer) toolCall(name string, args map[string]interface{}) string {
+	return fmt.Sprintf("<div class=\"tool-call\">⚡ %s<pre>%s</pre></div>", html.EscapeString(name), htmlText(prettyJSON(args)))
+}
+
+func (htmlRenderer) toolResult(name, text string, isError bool, details string) string {
+	class := "tool-result"
+	label := "→"
+	if isError {
+		class += " error"
+		label = "✗"
+	}
+	if name != "" {
+		label += " " + name
+	}
+	return fmt.Sprintf("<details class=\"%s\"><summary>%s <span class=\"meta\">(%s)</span></summary><pre>%s</pre></details>",
+		class, html.EscapeString(label), html.EscapeString(details), htmlText(text))
+}
+
+func (htmlRenderer) note(label, ts, text string) string {
+	line := fmt.Sprintf("<div class=\"note %s\">[%s]", label, html.EscapeString(label))
+	if ts != "" {
+		line += fmt.Sprintf(" <span class=\"meta\">%s</span>", html.EscapeString(ts))
+	}
+	if text != "" {
+		line += " " + htmlText(text)
+	}
+	return line + "</div>"
+}
+
+func (htmlRenderer) group(blocks []string) string {
+	return strings.Join(blocks, "\n")
+}
+
+func (htmlRenderer) summary(t *sessionTotals) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<div class=\"summary\">Total: %s", html.EscapeString(t.String()))
+	if byModelMode && len(t.ByModel) > 0 {
+		b.WriteString("\n<table>\n<tr><th>model</th><th>msgs</th><th>tokens</th><th>out</th><th>cost</th></tr>\n")
+		for _, name := range t.sortedModels() {
+			m := t.ByModel[name]
+			fmt.Fprintf(&b, "<tr><td>%s</td><td>%d</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				html.EscapeString(name), m.Messages, formatNumber(m.Tokens), formatNumber(m.Output), approxCost(m.Cost, m.Estimated))
+		}
+		b.WriteString("</table>")
+	}
+	b.WriteString("</div>")
+	return b.String()
+}
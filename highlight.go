@@ -0,0 +1,107 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Kinds of line in message text, as classified by codeLines
+const (
+	proseLine = iota
+	fenceLine
+	codeLine
+)
+
+// fenceLength returns the number of backticks opening a ``` fence line, or 0
+func fenceLength(line string) int {
+	trimmed := strings.TrimSpace(line)
+	n := len(trimmed) - len(strings.TrimLeft(trimmed, "`"))
+	if n < 3 {
+		return 0
+	}
+	return n
+}
+
+// codeLines classifies each line as prose, a fence, or code inside a fenced
+// block. Like markdown, a block only closes on a bare run of at least as
+// many backticks, so a ```lang line inside a block is code. An unterminated
+// fence leaves its lines as prose.
+func codeLines(lines []string) []int {
+	kinds := make([]int, len(lines))
+	open, length := -1, 0
+	for i, line := range lines {
+		n := fenceLength(line)
+		if open < 0 {
+			if n > 0 {
+				open, length = i, n
+			}
+			continue
+		}
+		if n >= length && strings.Trim(strings.TrimSpace(line), "`") == "" {
+			kinds[open], kinds[i] = fenceLine, fenceLine
+			for j := open + 1; j < i; j++ {
+				kinds[j] = codeLine
+			}
+			open = -1
+		}
+	}
+	return kinds
+}
+
+// styleCodeBlocks sets fenced code in a message body apart from the prose
+// around it: fences are dimmed and code is indented one more level without
+// the message color. URLs and paths in the prose are highlighted unless
+// --no-highlight is set. color is the message's color, restored after each
+// line.
+func styleCodeBlocks(text, color string) string {
+	lines := strings.Split(text, "\n")
+	for i, kind := range codeLines(lines) {
+		switch kind {
+		case proseLine:
+			if highlightMode {
+				lines[i] = highlightRefs(lines[i], color)
+			}
+		case fenceLine:
+			lines[i] = reset + dim + lines[i] + reset + color
+		case codeLine:
+			lines[i] = reset + indentation(1) + lines[i] + color
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Global flag: highlight URLs and file paths in message text
+var highlightMode = true
+
+// refPattern matches URLs, absolute or ~/./.. paths, and relative paths
+// with a directory and an extension like src/main.go. Matches must start a
+// word; see highlightRefs.
+var refPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+|(?:~|\.\.?)?/[\w.\-@+]+(?:/[\w.\-@+]*)*|[\w.\-]+(?:/[\w.\-@+]+)+\.[A-Za-z0-9]+`)
+
+// highlightRefs underlines URLs and colors file paths in a line of prose.
+// color is the line's color, restored after each highlight.
+func highlightRefs(line, color string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range refPattern.FindAllStringIndex(line, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 && !strings.ContainsRune(" \t([{<\"'`=:", rune(line[start-1])) {
+			continue
+		}
+		// Sentence punctuation after a reference isn't part of it
+		end = start + len(strings.TrimRight(line[start:end], ".,;:!?)]}"))
+		ref := line[start:end]
+		if ref == "/" || ref == "~/" || ref == "./" {
+			continue
+		}
+		style := blue
+		if strings.Contains(ref, "://") {
+			style = blue + underline
+		}
+		b.WriteString(line[last:start])
+		b.WriteString(style + ref + reset + color)
+		last = end
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
@@ -2,19 +2,31 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
-// ANSI color codes
-const (
+// ANSI color codes, set by applyTheme and cleared by disableColors for plain
+// output
+var (
 	cyan    = "\033[36m"
 	green   = "\033[32m"
 	yellow  = "\033[33m"
@@ -24,6 +36,8 @@ const (
 	reset   = "\033[0m"
 	magenta = "\033[35m"
 	blue    = "\033[34m"
+	// underline marks URLs in message text
+	underline = "\033[4m"
 )
 
 const (
@@ -31,6 +45,35 @@ const (
 	defaultTail  = 20
 )
 
+// Follow mode timing: pollInterval is used when filesystem notifications
+// are unavailable; watchTimeout bounds each notification wait so the file
+// is still re-checked periodically.
+const (
+	defaultPollInterval = 300 * time.Millisecond
+	watchTimeout        = 2 * time.Second
+)
+
+// Global follow-mode polling interval, set with --poll-interval
+var pollInterval = defaultPollInterval
+
+// checkPollInterval rejects a --poll-interval that would spin on a file
+// without notifications
+func checkPollInterval(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("must be greater than 0")
+	}
+	return nil
+}
+
+// waitForChange blocks until a followed file may have grown: until watcher
+// fires or watchTimeout passes, or for pollInterval when notifications are
+// unavailable (watcher is nil) or the watcher fails
+func waitForChange(watcher *fileWatcher) {
+	if watcher == nil || watcher.wait(watchTimeout) != nil {
+		time.Sleep(pollInterval)
+	}
+}
+
 // Message structures
 type Cost struct {
 	Input      float64 `json:"input"`
@@ -38,6 +81,23 @@ type Cost struct {
 	CacheRead  float64 `json:"cacheRead"`
 	CacheWrite float64 `json:"cacheWrite"`
 	Total      float64 `json:"total"`
+	// Estimated is set for costs computed from --price rather than logged
+	Estimated bool `json:"-"`
+}
+
+// UnmarshalJSON also accepts a bare number, as a cost placed outside usage
+// often is, taking it as the total
+func (c *Cost) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] != '{' {
+		var total lenientFloat
+		if err := json.Unmarshal(trimmed, &total); err != nil {
+			return err
+		}
+		*c = Cost{Total: float64(total)}
+		return nil
+	}
+	type plain Cost
+	return json.Unmarshal(data, (*plain)(c))
 }
 
 type Usage struct {
@@ -49,16 +109,74 @@ type Usage struct {
 	Cost        *Cost `json:"cost"`
 }
 
+// UnmarshalJSON reads token counts that some providers send as strings
+// ("1000") or floats (196.0) as well as integers
+func (u *Usage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Input       lenientInt `json:"input"`
+		Output      lenientInt `json:"output"`
+		CacheRead   lenientInt `json:"cacheRead"`
+		CacheWrite  lenientInt `json:"cacheWrite"`
+		TotalTokens lenientInt `json:"totalTokens"`
+		Cost        *Cost      `json:"cost"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*u = Usage{
+		Input:       int(raw.Input),
+		Output:      int(raw.Output),
+		CacheRead:   int(raw.CacheRead),
+		CacheWrite:  int(raw.CacheWrite),
+		TotalTokens: int(raw.TotalTokens),
+		Cost:        raw.Cost,
+	}
+	return nil
+}
+
+// lenientInt is a JSON number or numeric string, rounded to an int
+type lenientInt int
+
+func (n *lenientInt) UnmarshalJSON(data []byte) error {
+	var f lenientFloat
+	if err := f.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*n = lenientInt(math.Round(float64(f)))
+	return nil
+}
+
+// lenientFloat is a JSON number or numeric string
+type lenientFloat float64
+
+func (f *lenientFloat) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*f = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*f = lenientFloat(v)
+	return nil
+}
+
 type Message struct {
 	Role    string      `json:"role"`
 	Content interface{} `json:"content"`
 	Usage   *Usage      `json:"usage"`
+	Model   string      `json:"model"`
+	Cost    *Cost       `json:"cost"`
 }
 
 type LogEntry struct {
 	// OpenClaw format
 	Message   Message     `json:"message"`
 	Timestamp interface{} `json:"timestamp"`
+	// Cost outside usage; usage.cost wins when both are present
+	Cost *Cost `json:"cost"`
 	
 	// Inber format (flat structure)
 	Role       string                 `json:"role"`
@@ -78,6 +196,476 @@ type LogEntry struct {
 // Global verbose flag
 var verboseMode bool
 
+// Global full-output flag; disables all truncation
+var fullMode bool
+
+// Truncation limits, in bytes; 0 disables a limit. maxTextLen, maxResultLen,
+// and maxArgLen are set from --max-text, --max-result, and --max-args.
+var (
+	maxTextLen    = 500 // user and thinking text longer than this is previewed
+	maxResultLen  = 300
+	maxArgLen     = 80
+	maxRawArgsLen = 150
+	maxSystemLen  = 200
+)
+
+// truncate shortens s to at most limit bytes, ending in "…" and never
+// splitting a UTF-8 sequence. In --full mode s is returned unchanged.
+func truncate(s string, limit int) string {
+	if fullMode || limit <= 0 || len(s) <= limit {
+		return s
+	}
+	return cutString(s, max(limit-3, 0)) + "…"
+}
+
+// Global flag: show thinking text in full, leaving other limits in place
+var fullThinkingMode bool
+
+// previewText shortens text longer than maxTextLen to a preview of its
+// first two fifths followed by a dim "… (N bytes)" note. In --full mode
+// text is returned unchanged.
+func previewText(text string) string {
+	if fullMode || maxTextLen <= 0 || len(text) <= maxTextLen {
+		return text
+	}
+	return cutString(text, maxTextLen*2/5) + fmt.Sprintf("\n  %s… (%d bytes)%s", dim, len(text), reset)
+}
+
+// cutString returns the longest prefix of s that is at most n bytes and
+// ends on a rune boundary
+func cutString(s string, n int) string {
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// Global pretty-printed tool arguments flag
+var prettyArgsMode bool
+
+// Global flag: indent and highlight tool results that are JSON. Also
+// enabled by --pretty-args.
+var prettyResultsMode bool
+
+// Global flag to show edit tool arguments as plain summaries instead of diffs
+var noDiffMode bool
+
+// Global wrap width for message text; 0 disables wrapping
+var wrapWidth int
+
+// visibleWidth returns the number of runes in s, ignoring ANSI escape sequences
+func visibleWidth(s string) int {
+	width := 0
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case inEscape:
+			if r == 'm' {
+				inEscape = false
+			}
+		case r == '\033':
+			inEscape = true
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// wrapText soft-wraps text at word boundaries to the given width. Code is
+// left alone: fenced blocks and lines indented with a tab or four spaces are
+// never wrapped.
+func wrapText(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	kinds := codeLines(lines)
+	var out []string
+	for i, line := range lines {
+		if kinds[i] != proseLine || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ") || visibleWidth(line) <= width {
+			out = append(out, line)
+			continue
+		}
+		out = append(out, wrapLine(line, width)...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// wrapLine breaks a single line into lines of at most width visible runes,
+// repeating the line's leading indentation on each continuation. Words
+// longer than width are left on a line of their own.
+func wrapLine(line string, width int) []string {
+	indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+	var lines []string
+	current := indent
+	currentWidth := len(indent)
+	for _, word := range strings.Fields(line) {
+		w := visibleWidth(word)
+		if currentWidth > len(indent) && currentWidth+1+w > width {
+			lines = append(lines, current)
+			current, currentWidth = indent, len(indent)
+		}
+		if currentWidth > len(indent) {
+			current += " "
+			currentWidth++
+		}
+		current += word
+		currentWidth += w
+	}
+	return append(lines, current)
+}
+
+// Global indentation width for content nested under a message header
+var indentWidth = 2
+
+// indentation returns the prefix for the given nesting level: message
+// bodies and tool activity sit at level 1, tool details below that
+func indentation(level int) string {
+	return strings.Repeat(" ", level*max(indentWidth, 0))
+}
+
+// indentContinuation indents every line after the first to the given level,
+// so multi-line tool output stays under its "→" marker
+func indentContinuation(text string, level int) string {
+	return strings.ReplaceAll(text, "\n", "\n"+indentation(level))
+}
+
+// formatBody wraps message text to fit the terminal and indents it under
+// its header
+func formatBody(text string) string {
+	text = wrapText(text, wrapWidth-len(indentation(1)))
+	prefix := indentation(1)
+	if prefix == "" {
+		return text
+	}
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+// Output formats selected with --format
+const (
+	formatText     = "text"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+	formatCSV      = "csv"
+	formatTSV      = "tsv"
+)
+
+// Global output format
+var outputFormat = formatText
+
+// Global reverse flag: dump newest messages first
+var reverseMode bool
+
+// renderLine renders a JSONL line in the selected output format, applying
+// any redaction rules. Document formats redact as they render, so a pattern
+// can't rewrite their markup.
+func renderLine(line string) ProcessedLine {
+	if r := docRendererFor(outputFormat); r != nil {
+		return processDocLine(line, r)
+	}
+	var result ProcessedLine
+	if isTableFormat(outputFormat) {
+		result = processUsageLine(line)
+	} else if summaryOnlyMode {
+		result = processDigestLine(line)
+	} else {
+		result = processLine(line)
+	}
+	result.Output = redactOutput(result.Output)
+	return result
+}
+
+// Global flag: warn on stderr about lines that aren't valid JSON
+var showErrorsMode bool
+
+// renderAt renders line n of a session (1-based; 0 if unknown), warning
+// about malformed JSON when --show-errors is set and about out-of-order
+// timestamps when --check-order is
+func renderAt(line string, n int) ProcessedLine {
+	result := renderLine(line)
+	if result.ParseError != nil && showErrorsMode {
+		// Keep warnings next to the output around them
+		flushStdout()
+		fmt.Fprintln(os.Stderr, formatParseError(line, n, result.ParseError))
+	}
+	if orderCheck != nil {
+		if warning := orderCheck.check(result, n); warning != "" {
+			flushStdout()
+			fmt.Fprintln(os.Stderr, warning)
+		}
+	}
+	if dedupe != nil {
+		result.dedupeKey = dedupe.keyOf(result)
+	}
+	if lineNumbersMode && outputFormat == formatText {
+		result.Output = numberLines(result.Output, n)
+	}
+	return result
+}
+
+// deduper collapses runs of identical output into one, like uniq -c. The
+// latest output is held back until a different one arrives or it's flushed.
+type deduper struct {
+	// exact compares timestamps too; by default they're ignored
+	exact bool
+	held  string
+	key   string
+	count int
+}
+
+// Global deduper from --dedupe; nil when disabled
+var dedupe *deduper
+
+// keyOf returns the comparison key for a rendered line
+func (d *deduper) keyOf(result ProcessedLine) string {
+	if d.exact || result.Clock == "" {
+		return result.Output
+	}
+	return strings.ReplaceAll(result.Output, result.Clock, "")
+}
+
+// add records output and returns whatever is ready to print: the previous
+// run once it ends, or "" while output repeats
+func (d *deduper) add(output, key string) string {
+	if output == "" {
+		return ""
+	}
+	if key == "" {
+		key = output
+	}
+	if d.count > 0 && key == d.key {
+		d.count++
+		return ""
+	}
+	ready := d.flush()
+	d.held, d.key, d.count = output, key, 1
+	return ready
+}
+
+// flush returns the held run, with a "(×N)" suffix if it repeated
+func (d *deduper) flush() string {
+	if d.count == 0 {
+		return ""
+	}
+	output := d.held
+	if d.count > 1 {
+		output += fmt.Sprintf(" %s(×%d)%s", dim, d.count, reset)
+	}
+	d.held, d.key, d.count = "", "", 0
+	return output
+}
+
+// flushDedupe prints the run held by --dedupe, if any
+func flushDedupe() {
+	if dedupe != nil {
+		if output := dedupe.flush(); output != "" {
+			emit(output)
+		}
+	}
+}
+
+// finishOutput prints whatever is still held back at the end of a dump:
+// the last --dedupe run and the rule closing the last turn
+func finishOutput() {
+	flushDedupe()
+	closeTurn()
+}
+
+// Global flag: box each user-initiated turn with a numbered header and a
+// closing rule
+var groupByTurnMode bool
+
+// turnNumber counts the turns started so far; turnOpen is set while one
+// is being printed
+var (
+	turnNumber int
+	turnOpen   bool
+)
+
+// startTurn closes the current turn and opens the next
+func startTurn() {
+	closeTurn()
+	turnNumber++
+	label := fmt.Sprintf("┌─ Turn %d ", turnNumber)
+	emit(fmt.Sprintf("\n%s%s%s%s", dim, label, strings.Repeat("─", max(60-utf8.RuneCountInString(label), 0)), reset))
+	turnOpen = true
+}
+
+// closeTurn draws the rule ending the current turn, if one is open
+func closeTurn() {
+	if turnOpen {
+		emit(fmt.Sprintf("%s└%s%s", dim, strings.Repeat("─", 59), reset))
+		turnOpen = false
+	}
+}
+
+// Global flag: prefix each message with its source line number
+var lineNumbersMode bool
+
+// Width of the right-aligned line number gutter
+const lineNumberWidth = 5
+
+// numberLines prefixes the first line of output with n, dim and right-aligned,
+// and pads the rest so bodies stay aligned. Leading blank separators are
+// left bare; n of 0 (unknown) leaves an empty gutter.
+func numberLines(output string, n int) string {
+	if output == "" {
+		return output
+	}
+	gutter := strings.Repeat(" ", lineNumberWidth+1)
+	lines := strings.Split(output, "\n")
+	numbered := false
+	for i, line := range lines {
+		if line == "" && !numbered {
+			continue
+		}
+		if !numbered && n > 0 {
+			lines[i] = fmt.Sprintf("%s%*d%s %s", dim, lineNumberWidth, n, reset, line)
+		} else {
+			lines[i] = gutter + line
+		}
+		numbered = true
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatParseError describes a malformed line with its number and a
+// truncated copy of the raw text
+func formatParseError(line string, n int, err error) string {
+	where := "invalid JSON"
+	if n > 0 {
+		where = fmt.Sprintf("line %d: invalid JSON", n)
+	}
+	return fmt.Sprintf("%s%s: %v\n  %s%s", dim, where, err, truncate(strings.TrimSpace(line), maxRawArgsLen), reset)
+}
+
+// orderChecker warns about timestamps that go backwards, or messages that
+// lack one in a session that otherwise has them, for --check-order
+type orderChecker struct {
+	prev time.Time
+}
+
+// Global timestamp order checker; nil unless --check-order is set
+var orderCheck *orderChecker
+
+// check returns a warning for line n (0 if unknown) of a session, or ""
+func (c *orderChecker) check(result ProcessedLine, n int) string {
+	where := "entry"
+	if n > 0 {
+		where = fmt.Sprintf("line %d", n)
+	}
+	if !result.HasTimestamp {
+		if c.prev.IsZero() || result.Role == "" {
+			return ""
+		}
+		return fmt.Sprintf("%s%s: %s message has no timestamp%s", dim, where, result.Role, reset)
+	}
+	prev := c.prev
+	c.prev = result.Timestamp
+	if prev.IsZero() || !result.Timestamp.Before(prev) {
+		return ""
+	}
+	at := result.Timestamp
+	if timeZone != nil {
+		at = at.In(timeZone)
+	}
+	return fmt.Sprintf("%s%s: timestamp %s is %s before the previous entry's%s", dim, where,
+		at.Format(time.RFC3339), formatDuration(prev.Sub(result.Timestamp)), reset)
+}
+
+// Global per-model breakdown flag
+var byModelMode bool
+
+// Global tool allowlist; when non-empty only these tools' calls and results
+// are shown
+var toolFilter map[string]bool
+
+// Global flag: show only conversation text, hiding all tool activity
+var onlyTextMode bool
+
+// toolAllowed reports whether activity for the named tool should be shown
+func toolAllowed(name string) bool {
+	if onlyTextMode {
+		return false
+	}
+	return len(toolFilter) == 0 || toolFilter[strings.ToLower(name)]
+}
+
+// isToolRole reports whether a normalized role carries only tool activity
+func isToolRole(role string) bool {
+	return role == "tool_call" || role == "tool_result" || role == "tool"
+}
+
+// Thinking filters selected with --no-thinking and --only-thinking
+const (
+	thinkingHide = "hide"
+	thinkingOnly = "only"
+)
+
+// Global thinking filter; "" shows thinking alongside everything else
+var thinkingFilter string
+
+// roleAllowed reports whether entries with a normalized role pass the
+// --only-text and thinking filters
+func roleAllowed(role string) bool {
+	if onlyTextMode && isToolRole(role) {
+		return false
+	}
+	switch thinkingFilter {
+	case thinkingHide:
+		return role != "thinking"
+	case thinkingOnly:
+		return role == "thinking"
+	}
+	return true
+}
+
+// Global model filter; when non-empty only entries whose model contains one
+// of these names (case-insensitive) are shown
+var modelFilter []string
+
+// Global flag: with a model filter, also hide entries that carry no model
+var modelStrict bool
+
+// modelAllowed reports whether an entry from the named model should be shown.
+// Entries without a model (user, system, tool results) pass unless
+// modelStrict is set.
+func modelAllowed(model string) bool {
+	if len(modelFilter) == 0 {
+		return true
+	}
+	if model == "" {
+		return !modelStrict
+	}
+	model = strings.ToLower(model)
+	for _, want := range modelFilter {
+		if strings.Contains(model, strings.ToLower(want)) {
+			return true
+		}
+	}
+	return false
+}
+
+// stringList is a repeatable string flag that also accepts comma-separated values
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
 type ContentBlock struct {
 	Type      string                 `json:"type"`
 	Text      string                 `json:"text"`
@@ -86,6 +674,8 @@ type ContentBlock struct {
 	Content   interface{}            `json:"content"`
 }
 
+// getStateDir returns the default state directory: $OPENCLAW_STATE_DIR or
+// ~/.openclaw. --state-dir overrides both.
 func getStateDir() string {
 	stateDir := os.Getenv("OPENCLAW_STATE_DIR")
 	if stateDir == "" {
@@ -95,8 +685,8 @@ func getStateDir() string {
 	return stateDir
 }
 
-func getAgentsDir() string {
-	return filepath.Join(getStateDir(), "agents")
+func getAgentsDir(stateDir string) string {
+	return filepath.Join(stateDir, "agents")
 }
 
 type AgentInfo struct {
@@ -104,8 +694,11 @@ type AgentInfo struct {
 	Count int
 }
 
-func getAgents() []AgentInfo {
-	agentsDir := getAgentsDir()
+func getAgents(stateDir string) []AgentInfo {
+	if sessionGlob != "" {
+		return globAgents(sessionGlob)
+	}
+	agentsDir := getAgentsDir(stateDir)
 	entries, err := os.ReadDir(agentsDir)
 	if err != nil {
 		return []AgentInfo{}
@@ -118,8 +711,7 @@ func getAgents() []AgentInfo {
 		}
 		sessionsDir := filepath.Join(agentsDir, entry.Name(), "sessions")
 		if info, err := os.Stat(sessionsDir); err == nil && info.IsDir() {
-			pattern := filepath.Join(sessionsDir, "*.jsonl")
-			matches, _ := filepath.Glob(pattern)
+			matches := globSessions(sessionsDir)
 			agents = append(agents, AgentInfo{Name: entry.Name(), Count: len(matches)})
 		}
 	}
@@ -135,13 +727,154 @@ type SessionFile struct {
 	ModTime time.Time
 }
 
-func getSessions(agent string) []SessionFile {
-	pattern := filepath.Join(getAgentsDir(), agent, "sessions", "*.jsonl")
-	matches, err := filepath.Glob(pattern)
+func getSessions(stateDir, agent string) []SessionFile {
+	if sessionGlob != "" {
+		matches, _ := filepath.Glob(expandSessionGlob(sessionGlob, agent))
+		return sessionFiles(matches)
+	}
+	return sessionsIn(filepath.Join(getAgentsDir(stateDir), agent, "sessions"))
+}
+
+// sessionPatterns match session files, plain or gzip-archived
+var sessionPatterns = []string{"*.jsonl", "*.jsonl.gz"}
+
+// globSessions returns the paths of the session files in dir
+func globSessions(dir string) []string {
+	var matches []string
+	for _, pattern := range sessionPatterns {
+		found, _ := filepath.Glob(filepath.Join(dir, pattern))
+		matches = append(matches, found...)
+	}
+	return matches
+}
+
+// Global session path template from --glob or $OPENCLAW_SESSION_GLOB, such
+// as "/var/log/agents/{agent}/*.log"; empty uses the state directory layout
+var sessionGlob string
+
+// Placeholder for the agent id in a --glob template
+const agentPlaceholder = "{agent}"
+
+// expandSessionGlob fills in the agent id of a --glob template
+func expandSessionGlob(template, agent string) string {
+	return strings.ReplaceAll(template, agentPlaceholder, agent)
+}
+
+// globAgents lists the agents with sessions matching a --glob template,
+// reading each agent id from the path element holding the placeholder
+func globAgents(template string) []AgentInfo {
+	if !strings.Contains(template, agentPlaceholder) {
+		return []AgentInfo{}
+	}
+
+	matches, _ := filepath.Glob(expandSessionGlob(template, "*"))
+	counts := make(map[string]int)
+	for _, path := range matches {
+		if name, ok := globAgent(template, path); ok {
+			counts[name]++
+		}
+	}
+
+	agents := []AgentInfo{}
+	for name, count := range counts {
+		agents = append(agents, AgentInfo{Name: name, Count: count})
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		return agents[i].Name < agents[j].Name
+	})
+	return agents
+}
+
+// globAgent returns the agent id that a --glob template gives path, read
+// from the path element holding the placeholder, and whether path matches
+// the template at all
+func globAgent(template, path string) (string, bool) {
+	parts := strings.Split(filepath.ToSlash(template), "/")
+	elems := strings.Split(filepath.ToSlash(path), "/")
+	if len(elems) != len(parts) {
+		return "", false
+	}
+	agent := ""
+	for i, part := range parts {
+		if before, after, found := strings.Cut(part, agentPlaceholder); found {
+			agent = strings.TrimSuffix(strings.TrimPrefix(elems[i], before), after)
+			break
+		}
+	}
+	ok, _ := filepath.Match(expandSessionGlob(template, agent), path)
+	return agent, ok
+}
+
+// isGzip reports whether path is a gzip-compressed session
+func isGzip(path string) bool {
+	if isURL(path) {
+		if u, err := url.Parse(path); err == nil {
+			path = u.Path
+		}
+	}
+	return strings.HasSuffix(path, ".gz")
+}
+
+// isURL reports whether a session path is an http:// or https:// URL
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// openURL fetches a session over HTTP(S), failing on non-2xx responses
+func openURL(rawURL string) (io.ReadCloser, error) {
+	resp, err := http.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// openSession opens a session file or URL for reading, decompressing .gz
+// files
+func openSession(path string) (io.ReadCloser, error) {
+	var file io.ReadCloser
+	var err error
+	if isURL(path) {
+		file, err = openURL(path)
+	} else {
+		file, err = os.Open(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isGzip(path) {
+		return file, nil
+	}
+	gz, err := gzip.NewReader(file)
 	if err != nil {
-		return []SessionFile{}
+		file.Close()
+		return nil, err
 	}
+	return gzipFile{gz, file}, nil
+}
+
+// gzipFile closes both the gzip reader and the file underneath it
+type gzipFile struct {
+	*gzip.Reader
+	file io.Closer
+}
+
+func (g gzipFile) Close() error {
+	g.Reader.Close()
+	return g.file.Close()
+}
+
+// sessionsIn returns the session files in dir, newest first
+func sessionsIn(dir string) []SessionFile {
+	return sessionFiles(globSessions(dir))
+}
 
+// sessionFiles stats the given session paths and returns them newest first
+func sessionFiles(matches []string) []SessionFile {
 	var sessions []SessionFile
 	for _, path := range matches {
 		info, err := os.Stat(path)
@@ -157,12 +890,16 @@ func getSessions(agent string) []SessionFile {
 	return sessions
 }
 
-func findLatestSession(agent string) string {
-	sessions := getSessions(agent)
+func findLatestSession(stateDir, agent string) string {
+	sessions := getSessions(stateDir, agent)
 	if len(sessions) == 0 {
 		fmt.Fprintf(os.Stderr, "%sNo session files found for agent '%s'%s\n", red, agent, reset)
-		fmt.Fprintf(os.Stderr, "%sLooked in: %s/%s/sessions/*.jsonl%s\n", dim, getAgentsDir(), agent, reset)
-		agents := getAgents()
+		if sessionGlob != "" {
+			fmt.Fprintf(os.Stderr, "%sLooked in: %s%s\n", dim, expandSessionGlob(sessionGlob, agent), reset)
+		} else {
+			fmt.Fprintf(os.Stderr, "%sLooked in: %s/%s/sessions/*.jsonl[.gz]%s\n", dim, getAgentsDir(stateDir), agent, reset)
+		}
+		agents := getAgents(stateDir)
 		if len(agents) > 0 {
 			var names []string
 			for _, a := range agents {
@@ -187,12 +924,22 @@ func extractText(content interface{}) string {
 					if text, ok := blockMap["text"].(string); ok {
 						parts = append(parts, text)
 					}
+				} else if text := attachmentText(blockMap); text != "" {
+					parts = append(parts, text)
 				}
 			} else if str, ok := block.(string); ok {
 				parts = append(parts, str)
 			}
 		}
 		return strings.Join(parts, "\n")
+	case map[string]interface{}:
+		// A single block, or a wrapper like {"parts": [...]}
+		for _, key := range []string{"text", "content", "parts"} {
+			if inner, ok := v[key]; ok {
+				return extractText(inner)
+			}
+		}
+		return attachmentText(v)
 	default:
 		if v != nil {
 			return fmt.Sprintf("%v", v)
@@ -201,511 +948,2722 @@ func extractText(content interface{}) string {
 	}
 }
 
-func extractToolCalls(content interface{}) []string {
-	var calls []string
-	contentSlice, ok := content.([]interface{})
-	if !ok {
-		return calls
+// attachmentText returns a placeholder for an image or file content block,
+// such as "🖼 image (png, 1024x768)" or "📎 file: report.pdf", built from
+// whatever metadata the block carries. Other blocks return "".
+func attachmentText(block map[string]interface{}) string {
+	// Anthropic-style blocks keep their metadata in a nested source
+	meta := func(keys ...string) string {
+		for _, m := range []interface{}{block, block["source"]} {
+			fields, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, key := range keys {
+				if v, ok := fields[key].(string); ok && v != "" {
+					return v
+				}
+			}
+		}
+		return ""
+	}
+	kind := ""
+	if mime := meta("media_type", "mediaType", "mimeType", "mime_type"); mime != "" {
+		kind = mime[strings.LastIndex(mime, "/")+1:]
 	}
 
-	for _, block := range contentSlice {
-		blockMap, ok := block.(map[string]interface{})
-		if !ok {
-			continue
+	switch block["type"] {
+	case "image", "image_url":
+		var details []string
+		if kind != "" {
+			details = append(details, kind)
 		}
-		if blockMap["type"] != "toolCall" {
-			continue
+		width, _ := block["width"].(float64)
+		height, _ := block["height"].(float64)
+		if width > 0 && height > 0 {
+			details = append(details, fmt.Sprintf("%dx%d", int(width), int(height)))
 		}
-
-		name := "?"
-		if n, ok := blockMap["name"].(string); ok {
-			name = n
+		if len(details) == 0 {
+			return "🖼 image"
 		}
-
-		argsStr := ""
-		if args, ok := blockMap["arguments"].(map[string]interface{}); ok {
-			var summary []string
-			for k, v := range args {
-				vStr := fmt.Sprintf("%v", v)
-				if len(vStr) > 80 {
-					vStr = vStr[:77] + "…"
-				}
-				summary = append(summary, fmt.Sprintf("%s=%s", k, vStr))
-			}
-			argsStr = strings.Join(summary, ", ")
-		} else if args, ok := blockMap["arguments"]; ok {
-			argsStr = fmt.Sprintf("%v", args)
-			if len(argsStr) > 150 {
-				argsStr = argsStr[:150]
-			}
+		return fmt.Sprintf("🖼 image (%s)", strings.Join(details, ", "))
+	case "file", "document":
+		if name := meta("filename", "fileName", "name", "title", "path"); name != "" {
+			return "📎 file: " + name
 		}
-
-		calls = append(calls, fmt.Sprintf("  %s⚡ %s%s(%s%s%s)", magenta, name, reset, dim, argsStr, reset))
+		if kind != "" {
+			return fmt.Sprintf("📎 file (%s)", kind)
+		}
+		return "📎 file"
 	}
-	return calls
+	return ""
 }
 
-func extractToolResults(content interface{}) []string {
-	var results []string
+// contentBlocks returns the content blocks of the given type
+func contentBlocks(content interface{}, blockType string) []map[string]interface{} {
+	var blocks []map[string]interface{}
 	contentSlice, ok := content.([]interface{})
 	if !ok {
-		return results
+		return blocks
 	}
-
 	for _, block := range contentSlice {
-		blockMap, ok := block.(map[string]interface{})
-		if !ok {
-			continue
-		}
-		if blockMap["type"] != "toolResult" {
-			continue
-		}
-
-		text := ""
-		if t, ok := blockMap["text"].(string); ok {
-			text = t
-		} else if c, ok := blockMap["content"]; ok {
-			if cSlice, ok := c.([]interface{}); ok {
-				var parts []string
-				for _, item := range cSlice {
-					if itemMap, ok := item.(map[string]interface{}); ok {
-						if t, ok := itemMap["text"].(string); ok {
-							parts = append(parts, t)
-						}
-					}
-				}
-				text = strings.Join(parts, " ")
-			} else {
-				text = fmt.Sprintf("%v", c)
-			}
+		if blockMap, ok := block.(map[string]interface{}); ok && blockMap["type"] == blockType {
+			blocks = append(blocks, blockMap)
 		}
+	}
+	return blocks
+}
 
-		if len(text) > 300 {
-			text = text[:297] + "…"
-		}
-		if strings.TrimSpace(text) != "" {
-			results = append(results, fmt.Sprintf("  %s→ %s%s", dim, text, reset))
-		}
+// toolCallName returns the name of a toolCall block, or "?" if missing
+func toolCallName(block map[string]interface{}) string {
+	if n, ok := block["name"].(string); ok {
+		return n
 	}
-	return results
+	return "?"
 }
 
-func formatNumber(n int) string {
-	if n >= 1000 {
-		return fmt.Sprintf("%.1fk", float64(n)/1000)
+// summarizeArgs renders tool arguments as a one-line "k=v, k=v" summary
+// with keys in sorted order and long values truncated
+func summarizeArgs(args map[string]interface{}) string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
 	}
-	return fmt.Sprintf("%d", n)
+	sort.Strings(keys)
+
+	summary := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if str, ok := args[k].(string); ok {
+			if blob := describeBlob(str); blob != "" {
+				summary = append(summary, fmt.Sprintf("%s=%s", k, blob))
+				continue
+			}
+		}
+		summary = append(summary, fmt.Sprintf("%s=%s", k, truncate(fmt.Sprintf("%v", args[k]), maxArgLen)))
+	}
+	return strings.Join(summary, ", ")
 }
 
-func formatCost(cost float64) string {
-	if cost < 0.01 {
-		return fmt.Sprintf("$%.2f", cost)
+// Strings at least this long that use only the base64 alphabet are shown
+// as a size rather than a truncated garble
+const minBase64Blob = 256
+
+var (
+	base64Pattern  = regexp.MustCompile(`^(?:data:[\w/+.-]+;base64,)?[A-Za-z0-9+/_-]+={0,2}$`)
+	dataURIPattern = regexp.MustCompile(`^data:([\w/+.-]+);base64,`)
+)
+
+// describeBlob returns a placeholder such as "<42K base64>" or
+// "<3K binary>" for an argument value that can't usefully be previewed,
+// or "" for ordinary text
+func describeBlob(s string) string {
+	if !isPrintable(s) {
+		return fmt.Sprintf("<%s binary>", formatBytes(int64(len(s))))
 	}
-	return fmt.Sprintf("$%.2f", cost)
+	if len(s) >= minBase64Blob && base64Pattern.MatchString(s) {
+		kind := "base64"
+		if m := dataURIPattern.FindStringSubmatch(s); m != nil {
+			kind = m[1] + " base64"
+		}
+		return fmt.Sprintf("<%s %s>", formatBytes(int64(len(s))), kind)
+	}
+	return ""
 }
 
-func formatTokenUsage(usage *Usage) string {
-	if usage == nil || usage.TotalTokens == 0 && usage.Output == 0 {
-		return ""
+// isPrintable reports whether s is valid UTF-8 without control characters
+// other than whitespace
+func isPrintable(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
 	}
-	costStr := ""
-	if usage.Cost != nil && usage.Cost.Total > 0 {
-		costStr = fmt.Sprintf(" | %s", formatCost(usage.Cost.Total))
+	for _, r := range s {
+		if r < 0x20 && r != '\n' && r != '\r' && r != '\t' || r == 0x7f {
+			return false
+		}
 	}
-	return fmt.Sprintf(" %sctx: %s | out: %d%s%s", dim, formatNumber(usage.TotalTokens), usage.Output, costStr, reset)
+	return true
 }
 
-func formatTimestamp(entry *LogEntry) string {
-	var ts interface{}
-	if entry.TS != "" {
-		ts = entry.TS
-	} else if entry.Timestamp != nil {
-		ts = entry.Timestamp
-	} else {
-		return ""
+// prettyArgs renders tool arguments one per line with nested values as
+// indented JSON. Multi-line strings such as a file body are printed as a
+// literal block rather than a single escaped line.
+func prettyArgs(args map[string]interface{}, indent string) string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 
-	switch v := ts.(type) {
-	case float64:
-		t := v
-		if t > 1e12 {
-			t = t / 1000
+	var lines []string
+	for _, k := range keys {
+		if str, ok := args[k].(string); ok {
+			if blob := describeBlob(str); blob != "" {
+				lines = append(lines, fmt.Sprintf("%s%s: %s", indent, k, blob))
+				continue
+			}
 		}
-		return fmt.Sprintf(" %s%s%s", dim, time.Unix(int64(t), 0).Format("15:04:05"), reset)
-	case string:
-		return fmt.Sprintf(" %s%s%s", dim, v, reset)
-	default:
-		return ""
+		if str, ok := args[k].(string); ok && strings.Contains(str, "\n") {
+			lines = append(lines, fmt.Sprintf("%s%s: |", indent, k))
+			for _, line := range strings.Split(strings.TrimRight(str, "\n"), "\n") {
+				lines = append(lines, indent+"  "+line)
+			}
+			continue
+		}
+		value, err := json.MarshalIndent(args[k], indent, "  ")
+		if err != nil {
+			value = []byte(fmt.Sprintf("%v", args[k]))
+		}
+		lines = append(lines, fmt.Sprintf("%s%s: %s", indent, k, value))
 	}
+	return strings.Join(lines, "\n")
 }
 
-type ProcessedLine struct {
-	Output string
-	Usage  *Usage
+// editArgPairs are the old/new argument names used by common edit tools
+var editArgPairs = [][2]string{
+	{"old_string", "new_string"},
+	{"oldText", "newText"},
+	{"old_str", "new_str"},
 }
 
-// normalizeEntry converts an inber format entry to OpenClaw Message format
-func normalizeEntry(entry *LogEntry) (string, interface{}, *Usage, interface{}) {
-	// Check if this is inber format (has role at top level)
-	if entry.Role != "" && entry.Message.Role == "" {
-		// Inber format
-		role := entry.Role
-		content := entry.Content
-		ts := entry.TS
-		
-		// Build usage from inber fields
-		var usage *Usage
-		if entry.InTokens > 0 || entry.OutTokens > 0 {
-			usage = &Usage{
-				Input:       entry.InTokens,
-				Output:      entry.OutTokens,
-				TotalTokens: entry.InTokens,
-			}
-			if entry.CostUSD > 0 {
-				usage.Cost = &Cost{
-					Total: entry.CostUSD,
+// patchArgs are argument names that carry a unified diff
+var patchArgs = []string{"diff", "patch"}
+
+// maxDiffLines caps the rendered diff for a single tool call; --full shows all
+const maxDiffLines = 40
+
+// renderDiff renders edit-tool arguments as colorized diff lines. It returns
+// the rendered lines and the argument keys it consumed, or "" if the
+// arguments don't look like an edit.
+func renderDiff(args map[string]interface{}, indent string) (string, []string) {
+	var lines []string
+	var used []string
+	for _, pair := range editArgPairs {
+		oldStr, okOld := args[pair[0]].(string)
+		newStr, okNew := args[pair[1]].(string)
+		if !okOld || !okNew {
+			continue
+		}
+		if oldStr != "" {
+			for _, line := range strings.Split(strings.TrimRight(oldStr, "\n"), "\n") {
+				lines = append(lines, fmt.Sprintf("%s%s- %s%s", indent, red, line, reset))
+			}
+		}
+		if newStr != "" {
+			for _, line := range strings.Split(strings.TrimRight(newStr, "\n"), "\n") {
+				lines = append(lines, fmt.Sprintf("%s%s+ %s%s", indent, green, line, reset))
+			}
+		}
+		used = []string{pair[0], pair[1]}
+		break
+	}
+	if used == nil {
+		for _, key := range patchArgs {
+			patch, ok := args[key].(string)
+			if !ok || !strings.Contains(patch, "\n") {
+				continue
+			}
+			for _, line := range strings.Split(strings.TrimRight(patch, "\n"), "\n") {
+				color := dim
+				switch {
+				case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+					color = bold
+				case strings.HasPrefix(line, "@@"):
+					color = cyan
+				case strings.HasPrefix(line, "+"):
+					color = green
+				case strings.HasPrefix(line, "-"):
+					color = red
 				}
+				lines = append(lines, fmt.Sprintf("%s%s%s%s", indent, color, line, reset))
 			}
+			used = []string{key}
+			break
 		}
-		
-		return role, content, usage, ts
 	}
-	
-	// OpenClaw format
-	var ts interface{}
-	if entry.TS != "" {
-		ts = entry.TS
-	} else if entry.Timestamp != nil {
-		ts = entry.Timestamp
+	if used == nil {
+		return "", nil
+	}
+	if !fullMode && len(lines) > maxDiffLines {
+		more := len(lines) - maxDiffLines
+		lines = append(lines[:maxDiffLines], fmt.Sprintf("%s%s… (%d more lines)%s", indent, dim, more, reset))
 	}
-	return entry.Message.Role, entry.Message.Content, entry.Message.Usage, ts
+	return strings.Join(lines, "\n"), used
 }
 
-func processLine(line string) ProcessedLine {
-	line = strings.TrimSpace(line)
-	if line == "" {
-		return ProcessedLine{}
+// formatToolCall renders a tool call as "⚡ name(k=v, ...)", or with one
+// pretty-printed argument per line in --pretty-args or --full mode. Edit
+// tool arguments are shown as a colorized diff unless --no-diff is set.
+func formatToolCall(name string, args map[string]interface{}) string {
+	if !noDiffMode {
+		if diff, used := renderDiff(args, indentation(3)); diff != "" {
+			rest := make(map[string]interface{}, len(args))
+			for k, v := range args {
+				rest[k] = v
+			}
+			for _, k := range used {
+				delete(rest, k)
+			}
+			return fmt.Sprintf("%s%s⚡ %s%s(%s%s%s)\n%s", indentation(1), roleColor("tool_call"), name, reset, dim, summarizeArgs(rest), reset, diff)
+		}
 	}
-
-	var entry LogEntry
-	if err := json.Unmarshal([]byte(line), &entry); err != nil {
-		return ProcessedLine{}
+	if (prettyArgsMode || fullMode) && len(args) > 0 {
+		return fmt.Sprintf("%s%s⚡ %s%s\n%s%s%s", indentation(1), roleColor("tool_call"), name, reset, dim, prettyArgs(args, indentation(3)), reset)
 	}
+	return fmt.Sprintf("%s%s⚡ %s%s(%s%s%s)", indentation(1), roleColor("tool_call"), name, reset, dim, summarizeArgs(args), reset)
+}
 
-	role, content, usage, tsValue := normalizeEntry(&entry)
-	
-	if role == "" {
-		return ProcessedLine{}
+// jsonToken matches the strings (with a trailing colon for keys), numbers,
+// and literals of indented JSON
+var jsonToken = regexp.MustCompile(`"(?:[^"\\]|\\.)*"(?:\s*:)?|-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?|\btrue\b|\bfalse\b|\bnull\b`)
+
+// indentJSON returns text indented if it is a JSON object or array
+func indentJSON(text string) (string, bool) {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') || !json.Valid([]byte(trimmed)) {
+		return "", false
+	}
+	var b bytes.Buffer
+	if err := json.Indent(&b, []byte(trimmed), "", "  "); err != nil {
+		return "", false
 	}
+	return b.String(), true
+}
 
-	// Format timestamp
-	ts := ""
-	switch v := tsValue.(type) {
-	case string:
-		if v != "" {
-			// Try parsing as RFC3339 first (inber format)
-			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
-				ts = fmt.Sprintf(" %s%s%s", dim, t.Format("15:04:05"), reset)
-			} else if t, err := time.Parse(time.RFC3339, v); err == nil {
-				ts = fmt.Sprintf(" %s%s%s", dim, t.Format("15:04:05"), reset)
-			} else {
-				ts = fmt.Sprintf(" %s%s%s", dim, v, reset)
+// prettyResult renders a JSON tool result indented, with keys, strings, and
+// other values colored, keeping at most maxResultLen bytes of whole lines.
+// It returns false for results that aren't JSON.
+func prettyResult(text string) (string, bool) {
+	if !prettyResultsMode {
+		return "", false
+	}
+	indented, ok := indentJSON(text)
+	if !ok {
+		return "", false
+	}
+	lines := strings.Split(indented, "\n")
+	size, kept := 0, len(lines)
+	for i, line := range lines {
+		size += len(line) + 1
+		if !fullMode && maxResultLen > 0 && size > maxResultLen && i > 0 {
+			kept = i
+			break
+		}
+	}
+	for i, line := range lines[:kept] {
+		lines[i] = jsonToken.ReplaceAllStringFunc(line, func(token string) string {
+			switch {
+			case strings.HasSuffix(token, ":"):
+				return blue + token + reset
+			case strings.HasPrefix(token, `"`):
+				return green + token + reset
+			default:
+				return yellow + token + reset
 			}
+		})
+	}
+	body := strings.Join(lines[:kept], "\n")
+	if kept < len(lines) {
+		body += fmt.Sprintf("\n%s… %d more lines%s", dim, len(lines)-kept, reset)
+	}
+	return body, true
+}
+
+// formatPrettyResult lays out a prettyResult body under a "→" arrow
+func formatPrettyResult(name, body, elapsed string) string {
+	return fmt.Sprintf("%s%s→ %s%s%s%s%s%s", indentation(1), dim, toolLabel(name), reset, indentContinuation(body, 2), dim, elapsed, reset)
+}
+
+func extractToolCalls(content interface{}) []string {
+	var calls []string
+	for _, blockMap := range contentBlocks(content, "toolCall") {
+		name := toolCallName(blockMap)
+		if !toolAllowed(name) {
+			continue
 		}
-	case float64:
-		// Unix timestamp (OpenClaw format)
-		t := v
-		if t > 1e12 {
-			t = t / 1000
+
+		if args, ok := blockMap["arguments"].(map[string]interface{}); ok {
+			calls = append(calls, formatToolCall(name, args))
+			continue
+		}
+		argsStr := ""
+		if args, ok := blockMap["arguments"]; ok {
+			argsStr = truncate(fmt.Sprintf("%v", args), maxRawArgsLen)
 		}
-		ts = fmt.Sprintf(" %s%s%s", dim, time.Unix(int64(t), 0).Format("15:04:05"), reset)
+		calls = append(calls, fmt.Sprintf("%s%s⚡ %s%s(%s%s%s)", indentation(1), roleColor("tool_call"), name, reset, dim, argsStr, reset))
 	}
+	return calls
+}
 
-	switch role {
-	case "request":
-		// Inber format: full API request payload
-		// Skip by default unless verbose mode is enabled
-		if verboseMode {
-			return ProcessedLine{
-				Output: fmt.Sprintf("\n%s%s[request]%s%s%s", blue, dim, ts, reset, ""),
-			}
-		}
-		return ProcessedLine{}
-	
-	case "thinking":
-		// Inber format: reasoning text
-		text := extractText(content)
-		if text != "" {
-			if len(text) > 500 {
-				text = text[:200] + fmt.Sprintf("\n  %s… (%d chars)%s", dim, len(text), reset)
-			}
-			return ProcessedLine{
-				Output: fmt.Sprintf("\n%s%s💭 Thinking%s ━━━%s\n%s%s%s", yellow, bold, ts, reset, dim, text, reset),
+// toolResultText returns the text of a toolResult block, from its "text"
+// field or its nested content
+func toolResultText(block map[string]interface{}) string {
+	if t, ok := block["text"].(string); ok {
+		return t
+	}
+	c, ok := block["content"]
+	if !ok {
+		return ""
+	}
+	cSlice, ok := c.([]interface{})
+	if !ok {
+		return fmt.Sprintf("%v", c)
+	}
+	var parts []string
+	for _, item := range cSlice {
+		if itemMap, ok := item.(map[string]interface{}); ok {
+			if t, ok := itemMap["text"].(string); ok {
+				parts = append(parts, t)
 			}
 		}
-	
-	case "tool_call":
-		// Inber format: individual tool call
-		name := entry.ToolName
+	}
+	return strings.Join(parts, " ")
+}
+
+// extractToolResults renders toolResult blocks. at is the timestamp of the
+// containing message, used to annotate each result with its tool duration.
+func extractToolResults(content interface{}, at time.Time) []string {
+	var results []string
+	// Calls echoed in the same content array name the results that follow
+	for _, block := range contentBlocks(content, "toolCall") {
+		startToolCall(blockID(block), toolCallName(block), block["arguments"], at)
+	}
+	for _, blockMap := range contentBlocks(content, "toolResult") {
+		call, _ := toolPairs.finish(blockID(blockMap))
+		name, _ := blockMap["name"].(string)
 		if name == "" {
-			name = "?"
+			name = call.name
 		}
-		
-		argsStr := ""
-		if len(entry.ToolInput) > 0 {
-			var summary []string
-			for k, v := range entry.ToolInput {
-				vStr := fmt.Sprintf("%v", v)
-				if len(vStr) > 80 {
-					vStr = vStr[:77] + "…"
-				}
-				summary = append(summary, fmt.Sprintf("%s=%s", k, vStr))
-			}
-			argsStr = strings.Join(summary, ", ")
+		if !toolAllowed(name) {
+			continue
 		}
-		
-		return ProcessedLine{
-			Output: fmt.Sprintf("  %s⚡ %s%s(%s%s%s)", magenta, name, reset, dim, argsStr, reset),
+		elapsed := formatToolElapsed(call.elapsed(at))
+		raw := toolResultText(blockMap)
+		text := truncate(raw, maxResultLen)
+		if strings.TrimSpace(text) == "" {
+			continue
 		}
-	
-	case "tool_result":
-		// Inber format: individual tool result
-		text := extractText(content)
-		if entry.IsError {
-			if len(text) > 300 {
-				text = text[:297] + "…"
-			}
-			return ProcessedLine{
-				Output: fmt.Sprintf("  %s✗ %s%s", red, text, reset),
-			}
+		if body, ok := prettyResult(raw); ok && !compactMode && !blockIsError(blockMap) {
+			results = append(results, formatPrettyResult(name, body, elapsed))
+			continue
 		}
-		
-		// Count lines/bytes for non-error results
-		lineCount := strings.Count(text, "\n") + 1
-		byteCount := len(text)
-		if byteCount > 0 {
-			if lineCount == 1 && byteCount < 100 {
-				return ProcessedLine{
-					Output: fmt.Sprintf("  %s→ %s%s", dim, text, reset),
-				}
-			}
-			return ProcessedLine{
-				Output: fmt.Sprintf("  %s→ %d lines, %d bytes%s", dim, lineCount, byteCount, reset),
-			}
+		if compactMode {
+			results = append(results, compactToolResult(call, name, text, blockIsError(blockMap), elapsed))
+			continue
 		}
-		return ProcessedLine{}
-
-	case "user":
-		text := extractText(content)
-		if text != "" && !strings.HasPrefix(text, "Read HEARTBEAT") {
-			if len(text) > 500 {
-				text = text[:200] + fmt.Sprintf("\n  %s… (%d chars)%s", dim, len(text), reset)
-			}
-			return ProcessedLine{
-				Output: fmt.Sprintf("\n%s%s━━━ You%s ━━━%s\n%s%s%s", cyan, bold, ts, reset, cyan, text, reset),
-			}
+		if blockIsError(blockMap) {
+			results = append(results, fmt.Sprintf("%s%s✗ %s%s%s%s", indentation(1), red, toolLabel(name), indentContinuation(text, 2), elapsed, reset))
+		} else {
+			results = append(results, fmt.Sprintf("%s%s→ %s%s%s%s", indentation(1), dim, toolLabel(name), indentContinuation(text, 2), elapsed, reset))
 		}
+	}
+	return results
+}
 
-	case "assistant":
-		var parts []string
-		text := extractText(content)
-		tokens := formatTokenUsage(usage)
-		if strings.TrimSpace(text) != "" {
-			parts = append(parts, fmt.Sprintf("\n%s%s━━━ Agent%s%s ━━━%s\n%s%s%s", green, bold, ts, tokens, reset, green, text, reset))
-		}
-		toolCalls := extractToolCalls(content)
-		if len(toolCalls) > 0 {
-			if len(parts) == 0 {
-				parts = append(parts, fmt.Sprintf("\n%s%s━━━ Agent%s%s ━━━%s", green, bold, ts, tokens, reset))
-			}
-			parts = append(parts, toolCalls...)
+// toolLabel returns the "[name] " prefix naming the tool behind a result, or
+// "" when the tool is unknown
+func toolLabel(name string) string {
+	if name == "" || name == "?" {
+		return ""
+	}
+	return "[" + name + "] "
+}
+
+// blockIsError reports whether a toolResult block is flagged as a failure
+func blockIsError(block map[string]interface{}) bool {
+	for _, key := range []string{"isError", "is_error"} {
+		if isErr, ok := block[key].(bool); ok && isErr {
+			return true
 		}
-		if len(parts) > 0 {
-			return ProcessedLine{
-				Output: strings.Join(parts, "\n"),
-				Usage:  usage,
-			}
+	}
+	return false
+}
+
+// blockID returns the tool call ID carried by a toolCall or toolResult block
+func blockID(block map[string]interface{}) string {
+	for _, key := range []string{"toolCallId", "tool_use_id", "id"} {
+		if id, ok := block[key].(string); ok && id != "" {
+			return id
 		}
+	}
+	return ""
+}
 
-	case "tool":
-		results := extractToolResults(content)
-		if len(results) > 0 {
-			return ProcessedLine{
-				Output: strings.Join(results, "\n"),
-			}
+// toolTracker pairs tool calls with their results so a result knows which
+// tool produced it and how long it took. Calls are matched by ID when one
+// is available, otherwise in call order within the current turn.
+type toolTracker struct {
+	pending []pendingToolCall
+}
+
+type pendingToolCall struct {
+	id   string
+	name string
+	at   time.Time
+	// call is the one-line rendering of the call, held back by --compact
+	// until its result arrives
+	call string
+}
+
+// Global tool call tracker shared across processed lines
+var toolPairs = &toolTracker{}
+
+func (t *toolTracker) start(id, name string, at time.Time) {
+	t.pending = append(t.pending, pendingToolCall{id: id, name: name, at: at})
+}
+
+// finish removes and returns the call matching id, or the oldest call when
+// id is empty
+func (t *toolTracker) finish(id string) (pendingToolCall, bool) {
+	index := -1
+	for i, call := range t.pending {
+		if call.id == id {
+			index = i
+			break
 		}
-		text := extractText(content)
-		if strings.TrimSpace(text) != "" {
-			if len(text) > 300 {
-				text = text[:297] + "…"
-			}
-			return ProcessedLine{
-				Output: fmt.Sprintf("  %s→ %s%s", dim, text, reset),
-			}
+	}
+	if index < 0 {
+		if id != "" || len(t.pending) == 0 {
+			return pendingToolCall{}, false
 		}
+		index = 0
+	}
+	call := t.pending[index]
+	t.pending = append(t.pending[:index], t.pending[index+1:]...)
+	return call, true
+}
 
-	case "system":
-		text := extractText(content)
-		if strings.TrimSpace(text) != "" {
-			if len(text) > 200 {
-				text = text[:197] + "…"
-			}
-			return ProcessedLine{
-				Output: fmt.Sprintf("\n%s%s[system]%s %s%s", blue, dim, ts, text, reset),
-			}
+// reset drops unmatched calls at a turn boundary
+func (t *toolTracker) reset() {
+	t.pending = nil
+}
+
+// describe attaches a compact rendering to the most recently started call
+func (t *toolTracker) describe(call string) {
+	if len(t.pending) > 0 {
+		t.pending[len(t.pending)-1].call = call
+	}
+}
+
+// held returns the compact renderings of calls still waiting for a result
+func (t *toolTracker) held() []string {
+	var calls []string
+	for _, call := range t.pending {
+		if call.call != "" {
+			calls = append(calls, call.call)
 		}
 	}
+	return calls
+}
 
-	return ProcessedLine{}
+// Global compact flag: collapse each tool call and its result into one line
+var compactMode bool
+
+// startToolCall tracks a call and, in compact mode, holds its one-line
+// rendering until the result arrives
+func startToolCall(id, name string, args interface{}, at time.Time) {
+	toolPairs.start(id, name, at)
+	if compactMode && toolAllowed(name) {
+		toolPairs.describe(compactToolCall(name, args))
+	}
 }
 
-func streamFile(filepath string, follow bool, tail int) {
-	basename := filepath[strings.LastIndex(filepath, "/")+1:]
-	agentName := ""
-	parts := strings.Split(filepath, "/")
-	for i, p := range parts {
-		if p == "agents" && i+1 < len(parts) {
-			agentName = fmt.Sprintf(" (%s)", parts[i+1])
-			break
+// heldCalls returns the compact calls that never got a result, for printing
+// at the end of a dump
+func heldCalls() ProcessedLine {
+	return ProcessedLine{Output: strings.Join(toolPairs.held(), "\n")}
+}
+
+// compactToolCall renders a call on one line, ignoring --pretty-args and diffs
+func compactToolCall(name string, args interface{}) string {
+	if name == "" {
+		name = "?"
+	}
+	summary := ""
+	if m, ok := args.(map[string]interface{}); ok {
+		summary = summarizeArgs(m)
+	} else if args != nil {
+		summary = truncate(fmt.Sprintf("%v", args), maxRawArgsLen)
+	}
+	return fmt.Sprintf("%s%s⚡ %s%s(%s%s%s)", indentation(1), roleColor("tool_call"), name, reset, dim, summary, reset)
+}
+
+// compactToolResult renders a result on the same line as its held call, as
+// "⚡ exec(ls -la) → 3 lines"; results without a held call stand alone
+func compactToolResult(call pendingToolCall, name, text string, isError bool, elapsed string) string {
+	prefix := call.call + " "
+	if call.call == "" {
+		prefix = indentation(1) + toolLabel(name)
+	}
+	text = strings.TrimSpace(text)
+	if isError {
+		first, _, _ := strings.Cut(text, "\n")
+		return fmt.Sprintf("%s%s✗ %s%s%s", prefix, red, truncate(first, maxRawArgsLen), elapsed, reset)
+	}
+	lines := strings.Count(text, "\n") + 1
+	summary := fmt.Sprintf("%d %s", lines, plural(lines, "line"))
+	if !strings.Contains(text, "\n") && len(text) <= maxRawArgsLen {
+		summary = text
+	}
+	return fmt.Sprintf("%s%s→ %s%s%s", prefix, dim, summary, elapsed, reset)
+}
+
+// elapsed returns the time from the call to a result at the given time
+func (c pendingToolCall) elapsed(at time.Time) (time.Duration, bool) {
+	if c.at.IsZero() || at.IsZero() || at.Before(c.at) {
+		return 0, false
+	}
+	return at.Sub(c.at), true
+}
+
+// formatToolElapsed renders a tool duration as a " (1.2s)" suffix
+func formatToolElapsed(d time.Duration, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", formatElapsed(d))
+}
+
+// formatElapsed renders a short duration as "250ms", "1.2s", or "1m35s"
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
+// formatDuration renders a session-length duration for humans, keeping the
+// two largest units: "2d 3h", "1h 23m", "5m 12s", or "42s"
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	days, hours := int(d/(24*time.Hour)), int(d/time.Hour)%24
+	minutes, seconds := int(d/time.Minute)%60, int(d/time.Second)%60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm %ds", minutes, seconds)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
+
+// isoDuration renders d as an ISO-8601 duration such as "PT1H23M5S" or
+// "P2DT3H", to the second
+func isoDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	days := int(d / (24 * time.Hour))
+	d -= time.Duration(days) * 24 * time.Hour
+	var b strings.Builder
+	b.WriteString("P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if d == 0 {
+		if days == 0 {
+			return "PT0S"
+		}
+		return b.String()
+	}
+	b.WriteString("T")
+	for _, unit := range []struct {
+		size   time.Duration
+		suffix string
+	}{{time.Hour, "H"}, {time.Minute, "M"}, {time.Second, "S"}} {
+		if n := int(d / unit.size); n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, unit.suffix)
+			d -= time.Duration(n) * unit.size
+		}
+	}
+	return b.String()
+}
+
+func formatNumber(n int) string {
+	if n >= 1000 {
+		return fmt.Sprintf("%.1fk", float64(n)/1000)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// formatBytes renders a byte count as "512B", "42K", "1.5M", or "2.1G"
+func formatBytes(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	// Round before picking the unit, so 1048575 bytes is 1.0M, not 1024K
+	size := float64(n) / 1024
+	if k := math.Round(size); k < 1024 {
+		return fmt.Sprintf("%.0fK", k)
+	}
+	size /= 1024
+	if m := math.Round(size*10) / 10; m < 1024 {
+		return fmt.Sprintf("%.1fM", m)
+	}
+	return fmt.Sprintf("%.1fG", size/1024)
+}
+
+// Global display currency, set with --currency and --rate: costs are logged
+// in dollars and multiplied by currencyRate when shown. JSON and CSV output
+// keep the logged dollars.
+var (
+	currencySymbol = "$"
+	currencyRate   = 1.0
+)
+
+// currencySymbols maps currency codes to the symbol shown before amounts;
+// other codes are shown as "CODE "
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "¥",
+	"INR": "₹",
+	"KRW": "₩",
+	"BRL": "R$",
+}
+
+// setCurrency switches cost display to code at rate units per dollar
+func setCurrency(code string, rate float64) error {
+	code = strings.ToUpper(code)
+	if len(code) != 3 {
+		return fmt.Errorf("expected a 3-letter code such as EUR, got %q", code)
+	}
+	if rate <= 0 {
+		return fmt.Errorf("--rate must be greater than 0")
+	}
+	currencySymbol = code + " "
+	if symbol, ok := currencySymbols[code]; ok {
+		currencySymbol = symbol
+	}
+	currencyRate = rate
+	return nil
+}
+
+func formatCost(cost float64) string {
+	cost *= currencyRate
+	if cost < 0.01 {
+		return fmt.Sprintf("%s%.2f", currencySymbol, cost)
+	}
+	return fmt.Sprintf("%s%.2f", currencySymbol, cost)
+}
+
+// formatTokenUsage renders per-message usage in dim text, with the cost
+// colored by magnitude so expensive turns stand out
+func formatTokenUsage(usage *Usage) string {
+	text := usageTokens(usage)
+	if text == "" {
+		return ""
+	}
+	if usage.Cost == nil || usage.Cost.Total <= 0 {
+		return fmt.Sprintf(" %s%s%s", dim, text, reset)
+	}
+	color := costColor(usage.Cost.Total)
+	if color == dim {
+		return fmt.Sprintf(" %s%s | %s%s", dim, text, approxCost(usage.Cost.Total, usage.Cost.Estimated), reset)
+	}
+	return fmt.Sprintf(" %s%s | %s%s%s%s", dim, text, reset, color, approxCost(usage.Cost.Total, usage.Cost.Estimated), reset)
+}
+
+// Global per-message cost, in dollars, at and above which the cost is shown
+// in red (--cost-alert)
+var costAlert = 0.10
+
+// costColor picks the color for a per-message cost: dim under a cent,
+// yellow up to costAlert, red beyond it
+func costColor(cost float64) string {
+	switch {
+	case cost < 0.01:
+		return dim
+	case cost < costAlert:
+		return yellow
+	default:
+		return red
+	}
+}
+
+// usageText renders per-message usage as "tokens: N | out: N | $N" without
+// color. tokens is the message's total: input, output, and cache tokens.
+func usageText(usage *Usage) string {
+	text := usageTokens(usage)
+	if text == "" || usage.Cost == nil || usage.Cost.Total <= 0 {
+		return text
+	}
+	return text + " | " + approxCost(usage.Cost.Total, usage.Cost.Estimated)
+}
+
+// usageTokens renders the token counts of usageText, without the cost
+func usageTokens(usage *Usage) string {
+	if usage == nil || usage.TotalTokens == 0 && usage.Output == 0 {
+		return ""
+	}
+	cacheStr := ""
+	if showCacheMode {
+		cacheStr = cacheText(usage.CacheRead, usage.CacheWrite)
+	}
+	return fmt.Sprintf("tokens: %s | out: %d%s", formatNumber(usage.TotalTokens), usage.Output, cacheStr)
+}
+
+// Global flag: include cache tokens in per-message usage
+var showCacheMode bool
+
+// cacheText renders cache token counts as " | cache: N read / N write", or
+// "" when there are none
+func cacheText(read, write int) string {
+	if read == 0 && write == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" | cache: %s read / %s write", formatNumber(read), formatNumber(write))
+}
+
+// Global timestamp layout and zone, set with --time-format and --tz. A nil
+// zone leaves RFC3339 times in their own offset and epochs in local time.
+var (
+	timeFormat = "15:04:05"
+	timeZone   *time.Location
+)
+
+// timeFormatAliases are named layouts accepted by --time-format
+var timeFormatAliases = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"iso":      time.RFC3339,
+	"datetime": "2006-01-02 15:04:05",
+	"kitchen":  time.Kitchen,
+}
+
+// parseTimeFormat resolves a --time-format value to a Go time layout
+func parseTimeFormat(value string) string {
+	if layout, ok := timeFormatAliases[strings.ToLower(value)]; ok {
+		return layout
+	}
+	return value
+}
+
+// parseTimeZone resolves a --tz value: "local", "UTC", or an IANA zone name
+func parseTimeZone(value string) (*time.Location, error) {
+	if strings.EqualFold(value, "local") {
+		return time.Local, nil
+	}
+	return time.LoadLocation(value)
+}
+
+// formatTimestamp renders an entry timestamp with the configured layout and
+// zone, without color. Unrecognized strings are returned as-is.
+func formatTimestamp(value interface{}) string {
+	if t, ok := parseTimestamp(value); ok {
+		if timeZone != nil {
+			t = t.In(timeZone)
+		}
+		return t.Format(timeFormat)
+	}
+	if v, ok := value.(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Relative timestamp modes for --relative: follow mode shows age, dump mode
+// shows the gap since the previous entry
+const (
+	relativeNow      = "now"
+	relativePrevious = "previous"
+)
+
+// Global relative timestamp mode ("" for wall-clock times) and the last
+// entry time seen, for relativePrevious
+var (
+	relativeMode  string
+	lastEntryTime time.Time
+)
+
+// entryTimestamp renders an entry's timestamp for display, honoring
+// --relative. Entries without a parseable time fall back to formatTimestamp
+// and leave the previous-entry reference alone.
+func entryTimestamp(value interface{}) string {
+	t, ok := parseTimestamp(value)
+	if relativeMode == "" || !ok {
+		return formatTimestamp(value)
+	}
+	switch relativeMode {
+	case relativeNow:
+		return formatAgo(time.Since(t))
+	case relativePrevious:
+		prev := lastEntryTime
+		lastEntryTime = t
+		if prev.IsZero() {
+			return formatTimestamp(value)
+		}
+		return "+" + formatElapsed(max(t.Sub(prev), 0))
+	}
+	return formatTimestamp(value)
+}
+
+// formatAgo renders an age as "12s ago", "5m ago", "3h ago", or "2d ago"
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// heartbeatPattern matches the automated heartbeat prompt OpenClaw sends
+// ("Read HEARTBEAT.md if it exists ..."), hidden unless --show-heartbeat
+const heartbeatPattern = `(?i)^\s*read\s+heartbeat\b`
+
+// hideRule hides user messages matching a pattern, counting them under reason
+type hideRule struct {
+	pattern *regexp.Regexp
+	reason  string
+}
+
+// Global hide rules, from the heartbeat default and --hide
+var hideRules = []hideRule{{regexp.MustCompile(heartbeatPattern), skipHeartbeat}}
+
+// hideReason returns the skip reason for a user message matched by a hide
+// rule, or "" if the message should be shown
+func hideReason(text string) string {
+	for _, rule := range hideRules {
+		if rule.pattern.MatchString(text) {
+			return rule.reason
+		}
+	}
+	return ""
+}
+
+// setHideRules replaces the hide rules with patterns, plus the heartbeat
+// rule unless showHeartbeat is set
+func setHideRules(patterns []string, showHeartbeat bool) error {
+	var rules []hideRule
+	if !showHeartbeat {
+		rules = append(rules, hideRule{regexp.MustCompile(heartbeatPattern), skipHeartbeat})
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, hideRule{re, skipHidden})
+	}
+	hideRules = rules
+	return nil
+}
+
+// parseTimestamp converts an entry timestamp to a time.Time. Strings are
+// parsed as RFC3339 (inber format); numbers are Unix epochs in seconds or
+// milliseconds (OpenClaw format).
+func parseTimestamp(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, true
+		}
+	case float64:
+		if v > 1e12 {
+			return time.UnixMilli(int64(v)), true
+		}
+		return time.Unix(int64(v), 0), true
+	}
+	return time.Time{}, false
+}
+
+type ProcessedLine struct {
+	Output string
+	Usage  *Usage
+	// Role is the normalized entry role ("user", "assistant", "tool_call",
+	// ...); Model is the model that produced it, when the entry names one
+	Role  string
+	Model string
+	// Timestamp is the entry's parsed time; HasTimestamp is false when the
+	// entry had none or it couldn't be parsed
+	Timestamp    time.Time
+	HasTimestamp bool
+	// Clock is the timestamp as rendered in Output, "" if none
+	Clock string
+	// Skipped says why an entry produced no output; ParseError is set when
+	// the line wasn't valid JSON
+	Skipped    string
+	ParseError error
+
+	// dedupeKey identifies repeated output for --dedupe
+	dedupeKey string
+}
+
+// Reasons an entry is skipped, counted in the dump summary
+const (
+	skipParseError = "parse error"
+	skipMetadata   = "metadata"
+	skipEmpty      = "empty"
+	skipHeartbeat  = "heartbeat"
+	skipHidden     = "hidden"
+	skipRequest    = "request"
+	skipFiltered   = "filtered"
+	// Tool calls held by --compact to print with their result; not counted
+	skipCollapsed = "collapsed"
+	// Inber entries with only a timestamp, hidden by --no-heartbeat-timestamps
+	skipTimestampOnly = "timestamp-only"
+)
+
+// Global flag: show timestamp-only entries as dim ticks marking activity
+var heartbeatTimestamps = true
+
+// isTimestampOnly reports whether an inber entry carries only a timestamp,
+// with no role and empty content
+func isTimestampOnly(entry *LogEntry) bool {
+	return entry.TS != "" && entry.Role == "" && entry.Message.Role == "" &&
+		strings.TrimSpace(extractText(entry.Content)) == ""
+}
+
+// entryModel returns the model that produced an entry: the top-level field
+// in inber format, or the message's model in OpenClaw format
+func entryModel(entry *LogEntry) string {
+	if entry.Model != "" {
+		return entry.Model
+	}
+	return entry.Message.Model
+}
+
+// requestSummary describes an API request payload as "claude-sonnet-4,
+// 14 messages, 2 tools", leaving out whatever the payload lacks
+func requestSummary(req map[string]interface{}) string {
+	var parts []string
+	if model, ok := req["model"].(string); ok && model != "" {
+		parts = append(parts, model)
+	}
+	if messages, ok := req["messages"].([]interface{}); ok {
+		parts = append(parts, fmt.Sprintf("%d %s", len(messages), plural(len(messages), "message")))
+	}
+	if tools, ok := req["tools"].([]interface{}); ok && len(tools) > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", len(tools), plural(len(tools), "tool")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// prefixSpace returns text with a leading space, or "" if text is empty
+func prefixSpace(text string) string {
+	if text == "" {
+		return ""
+	}
+	return " " + text
+}
+
+// Global flag: name the model on each assistant header
+var showModelMode bool
+
+// modelLabel renders "[claude-sonnet-4]" for an assistant header when
+// --show-model is set and the entry names its model
+func modelLabel(model string) string {
+	if !showModelMode || model == "" {
+		return ""
+	}
+	return "[" + model + "]"
+}
+
+// lineEntry is a JSONL line parsed and normalized for a renderer
+type lineEntry struct {
+	entry   LogEntry
+	role    string
+	content interface{}
+	usage   *Usage
+	time    time.Time
+	hasTime bool
+	clock   string
+}
+
+// parseLine does the work every renderer shares before rendering a line: it
+// parses and normalizes the entry, then skips blank and malformed lines,
+// timestamp-only entries, metadata, and entries hidden by the model and role
+// filters. tick renders a timestamp-only entry when --heartbeat-timestamps
+// shows them; nil never shows them. When ok is false the line is finished
+// and done is the result to return.
+func parseLine(line string, tick func(clock string) string) (e *lineEntry, done ProcessedLine, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, ProcessedLine{}, false
+	}
+
+	e = &lineEntry{}
+	if err := json.Unmarshal([]byte(line), &e.entry); err != nil {
+		return nil, ProcessedLine{Skipped: skipParseError, ParseError: err}, false
+	}
+	var tsValue interface{}
+	e.role, e.content, e.usage, tsValue = normalizeEntry(&e.entry)
+	// Timestamps are read before any entry can be skipped so that relative
+	// times still measure from invisible entries
+	e.time, e.hasTime = parseTimestamp(tsValue)
+	e.clock = entryTimestamp(tsValue)
+
+	switch {
+	case isTimestampOnly(&e.entry):
+		done = ProcessedLine{Skipped: skipTimestampOnly}
+		if tick != nil && heartbeatTimestamps && e.clock != "" {
+			done = ProcessedLine{Output: tick(e.clock)}
+		}
+	case e.role == "":
+		done = ProcessedLine{Skipped: skipMetadata}
+	case !modelAllowed(entryModel(&e.entry)) || !roleAllowed(e.role):
+		done = ProcessedLine{Skipped: skipFiltered}
+	default:
+		return e, ProcessedLine{}, true
+	}
+	e.annotate(&done)
+	return e, done, false
+}
+
+// annotate records the entry's role, model, and time on a rendered result,
+// marking it empty if nothing was rendered or skipped
+func (e *lineEntry) annotate(result *ProcessedLine) {
+	result.Role, result.Model = e.role, entryModel(&e.entry)
+	result.Timestamp, result.HasTimestamp = e.time, e.hasTime
+	result.Clock = e.clock
+	if result.Output == "" && result.Skipped == "" {
+		result.Skipped = skipEmpty
+	}
+}
+
+// heartbeatTick renders a timestamp-only entry as a dim tick
+func heartbeatTick(clock string) string {
+	return fmt.Sprintf("%s%s· %s%s", indentation(1), dim, clock, reset)
+}
+
+func processLine(line string) (result ProcessedLine) {
+	e, done, ok := parseLine(line, heartbeatTick)
+	if !ok {
+		return done
+	}
+	defer e.annotate(&result)
+	entry, role, content, usage, tsTime := &e.entry, e.role, e.content, e.usage, e.time
+	estimateCost(usage, entryModel(entry))
+
+	ts := ""
+	if e.clock != "" {
+		ts = fmt.Sprintf(" %s%s%s", dim, e.clock, reset)
+	}
+
+	switch role {
+	case "request":
+		// Inber format: full API request payload
+		// Skip by default unless verbose mode is enabled
+		if verboseMode {
+			return ProcessedLine{
+				Output: fmt.Sprintf("\n%s%s[request]%s%s%s%s", roleColor("request"), dim, ts, roleColor("request")+dim, prefixSpace(requestSummary(entry.Request)), reset),
+			}
+		}
+		return ProcessedLine{Skipped: skipRequest}
+	
+	case "thinking":
+		// Inber format: reasoning text
+		text := extractText(content)
+		if text != "" {
+			if !fullThinkingMode {
+				text = previewText(text)
+			}
+			text = formatBody(text)
+			return ProcessedLine{
+				Output: fmt.Sprintf("\n%s%s💭 Thinking%s ━━━%s\n%s%s%s", roleColor("thinking"), bold, ts, reset, dim, text, reset),
+			}
+		}
+	
+	case "tool_call":
+		// Inber format: individual tool call
+		startToolCall(entry.ToolID, entry.ToolName, entry.ToolInput, tsTime)
+		name := entry.ToolName
+		if name == "" {
+			name = "?"
+		}
+		if !toolAllowed(name) {
+			return ProcessedLine{Skipped: skipFiltered}
+		}
+		if compactMode {
+			return ProcessedLine{Skipped: skipCollapsed}
+		}
+		
+		return ProcessedLine{
+			Output: formatToolCall(name, entry.ToolInput),
+		}
+	
+	case "tool_result":
+		// Inber format: individual tool result
+		call, _ := toolPairs.finish(entry.ToolID)
+		name := entry.ToolName
+		if name == "" {
+			name = call.name
+		}
+		if !toolAllowed(name) {
+			return ProcessedLine{Skipped: skipFiltered}
+		}
+		text := extractText(content)
+		elapsed := formatToolElapsed(call.elapsed(tsTime))
+		if compactMode && strings.TrimSpace(text) != "" {
+			return ProcessedLine{Output: compactToolResult(call, name, text, entry.IsError, elapsed)}
+		}
+		if entry.IsError {
+			text = truncate(text, maxResultLen)
+			return ProcessedLine{
+				Output: fmt.Sprintf("%s%s✗ %s%s%s", indentation(1), red, indentContinuation(text, 2), elapsed, reset),
+			}
+		}
+		
+		if body, ok := prettyResult(text); ok {
+			return ProcessedLine{Output: formatPrettyResult("", body, elapsed)}
+		}
+
+		// Count lines/bytes for non-error results
+		lineCount := strings.Count(text, "\n") + 1
+		byteCount := len(text)
+		if byteCount > 0 {
+			if fullMode || (lineCount == 1 && byteCount < 100) {
+				return ProcessedLine{
+					Output: fmt.Sprintf("%s%s→ %s%s%s", indentation(1), dim, indentContinuation(text, 2), elapsed, reset),
+				}
+			}
+			return ProcessedLine{
+				Output: fmt.Sprintf("%s%s→ %d lines, %d bytes%s%s", indentation(1), dim, lineCount, byteCount, elapsed, reset),
+			}
+		}
+		return ProcessedLine{}
+
+	case "user":
+		// A new user message starts a new turn; calls held by --compact
+		// that never got a result are printed on their own
+		held := strings.Join(toolPairs.held(), "\n")
+		toolPairs.reset()
+		text := extractText(content)
+		if reason := hideReason(text); reason != "" {
+			return ProcessedLine{Output: held, Skipped: reason}
+		}
+		if text != "" {
+			text = styleCodeBlocks(formatBody(previewText(text)), roleColor("user"))
+			return ProcessedLine{
+				Output: held + fmt.Sprintf("\n%s%s━━━ You%s ━━━%s\n%s%s%s", roleColor("user"), bold, ts, reset, roleColor("user"), text, reset),
+			}
+		}
+		return ProcessedLine{Output: held}
+
+	case "assistant":
+		var parts []string
+		text := styleCodeBlocks(formatBody(extractText(content)), roleColor("assistant"))
+		tokens := formatTokenUsage(usage)
+		model := prefixSpace(modelLabel(entryModel(entry)))
+		if strings.TrimSpace(text) != "" {
+			parts = append(parts, fmt.Sprintf("\n%s%s━━━ Agent%s%s%s ━━━%s\n%s%s%s", roleColor("assistant"), bold, model, ts, tokens, reset, roleColor("assistant"), text, reset))
+		}
+		callBlocks := contentBlocks(content, "toolCall")
+		for _, block := range callBlocks {
+			startToolCall(blockID(block), toolCallName(block), block["arguments"], tsTime)
+		}
+		var toolCalls []string
+		if !compactMode {
+			toolCalls = extractToolCalls(content)
+		} else if len(parts) == 0 && len(callBlocks) > 0 {
+			// Calls print with their results; tool-only turns get no header
+			return ProcessedLine{Usage: usage, Skipped: skipCollapsed}
+		}
+		if len(toolCalls) > 0 {
+			if len(parts) == 0 {
+				parts = append(parts, fmt.Sprintf("\n%s%s━━━ Agent%s%s%s ━━━%s", roleColor("assistant"), bold, model, ts, tokens, reset))
+			}
+			parts = append(parts, toolCalls...)
+		}
+		if len(parts) > 0 {
+			return ProcessedLine{
+				Output: strings.Join(parts, "\n"),
+				Usage:  usage,
+			}
+		}
+		if len(callBlocks) > 0 {
+			// Every call was filtered out, but the turn still cost tokens
+			return ProcessedLine{Usage: usage, Skipped: skipFiltered}
+		}
+
+	case "tool":
+		results := extractToolResults(content, tsTime)
+		if len(results) > 0 {
+			return ProcessedLine{
+				Output: strings.Join(results, "\n"),
+			}
+		}
+		text := extractText(content)
+		if strings.TrimSpace(text) != "" {
+			// Plain-text result: pair it with the oldest pending call
+			call, _ := toolPairs.finish("")
+			if !toolAllowed(call.name) {
+				return ProcessedLine{Skipped: skipFiltered}
+			}
+			if body, ok := prettyResult(text); ok && !compactMode {
+				return ProcessedLine{Output: formatPrettyResult(call.name, body, formatToolElapsed(call.elapsed(tsTime)))}
+			}
+			text = truncate(text, maxResultLen)
+			if compactMode {
+				return ProcessedLine{Output: compactToolResult(call, call.name, text, false, formatToolElapsed(call.elapsed(tsTime)))}
+			}
+			return ProcessedLine{
+				Output: fmt.Sprintf("%s%s→ %s%s%s%s", indentation(1), dim, toolLabel(call.name), indentContinuation(text, 2), formatToolElapsed(call.elapsed(tsTime)), reset),
+			}
+		}
+
+	case "system":
+		text := extractText(content)
+		if strings.TrimSpace(text) != "" {
+			text = truncate(text, maxSystemLen)
+			if highlightMode {
+				text = highlightRefs(text, roleColor("system")+dim)
+			}
+			return ProcessedLine{
+				Output: fmt.Sprintf("\n%s%s[system]%s %s%s", roleColor("system"), dim, ts, text, reset),
+			}
+		}
+	}
+
+	return ProcessedLine{}
+}
+
+func printStreamHeader(name string) {
+	if quietMode {
+		return
+	}
+	if r := docRendererFor(outputFormat); r != nil {
+		fmt.Fprint(stdout(), r.begin(name))
+		return
+	}
+	if isTableFormat(outputFormat) {
+		fmt.Fprintln(stdout(), tableRow(usageColumns))
+		return
+	}
+	fmt.Fprintf(stdout(), "%sStreaming: %s%s\n", yellow, name, reset)
+	fmt.Fprintf(stdout(), "%s%s%s\n\n", dim, strings.Repeat("─", 60), reset)
+}
+
+// printStreamFooter closes the document for formats that need it
+func printStreamFooter() {
+	if r := docRendererFor(outputFormat); r != nil {
+		fmt.Fprint(stdout(), r.end())
+	}
+	flushStdout()
+}
+
+// newLineScanner returns a line scanner sized for large JSONL entries
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024) // up to 10MB per line
+	return scanner
+}
+
+// countMessages returns how many messages in r would be shown with the
+// active filters, rendering each line without printing it
+func countMessages(r io.Reader) (int, error) {
+	count := 0
+	scanner := newLineScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		if lineSpan.past(n) {
+			break
+		}
+		if lineSpan.contains(n) && renderLine(scanner.Text()).Output != "" {
+			count++
+		}
+	}
+	return count, scanner.Err()
+}
+
+// countFile prints the number of messages in a session that pass the
+// active filters
+func countFile(path string) {
+	file, err := openSession(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sError opening file: %v%s\n", red, err, reset)
+		os.Exit(1)
+	}
+	defer file.Close()
+	n, err := countMessages(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sError reading file: %v%s\n", red, err, reset)
+		os.Exit(1)
+	}
+	fmt.Println(n)
+}
+
+// streamStdin reads JSONL from standard input line by line until the pipe
+// closes, then prints the session totals
+func streamStdin() {
+	printStreamHeader("stdin")
+
+	var totals sessionTotals
+	scanner := newLineScanner(os.Stdin)
+	for n := 1; scanner.Scan(); n++ {
+		if lineSpan.contains(n) {
+			printProcessed(renderAt(scanner.Text(), n), &totals)
+			// A pipe may be live (tail -f), so don't hold lines back
+			flushStdout()
+		}
+		if outputCapped {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "%sError reading stdin: %v%s\n", red, err, reset)
+	}
+	printProcessed(heldCalls(), &totals)
+	finishOutput()
+	totals.printSummary()
+	printStreamFooter()
+}
+
+func streamFile(filepath string, follow bool, tail int) {
+	defer flushStdout()
+	saveLastSession(filepath)
+	basename := filepath[strings.LastIndex(filepath, "/")+1:]
+	agentName := ""
+	parts := strings.Split(filepath, "/")
+	for i, p := range parts {
+		if p == "agents" && i+1 < len(parts) {
+			agentName = fmt.Sprintf(" (%s)", parts[i+1])
+			break
+		}
+	}
+
+	printStreamHeader(basename + agentName)
+
+	var file *os.File
+	var reader io.Reader
+	var err error
+	if isURL(filepath) || isGzip(filepath) {
+		// A fetched session is a snapshot and an archive doesn't grow, so
+		// there's nothing to follow: dump instead
+		body, err := openSession(filepath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sError opening file: %v%s\n", red, err, reset)
+			os.Exit(1)
+		}
+		defer body.Close()
+		reader = body
+		follow = false
+	} else {
+		file, err = os.Open(filepath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sError opening file: %v%s\n", red, err, reset)
+			os.Exit(1)
+		}
+		defer func() { file.Close() }()
+		reader = file
+	}
+
+	// Line number of the last line read, for --show-errors. Unknown after
+	// seeking with --tail-bytes, so numbering is off until a reopen.
+	lineNum := 0
+	numbered := true
+	lineAt := func() int {
+		if !numbered {
+			return 0
+		}
+		return lineNum
+	}
+
+	seeked := false
+	if tailBytes > 0 && file != nil {
+		if seeked, err = seekTailBytes(file, tailBytes); err != nil {
+			fmt.Fprintf(os.Stderr, "%sError seeking file: %v%s\n", red, err, reset)
+			os.Exit(1)
+		}
+		numbered = !seeked
+	}
+	if follow && file != nil {
+		// Stop the first read at the last newline: a line the agent is
+		// still writing is left for the follower to read once it's whole
+		if end, err := completeEnd(file); err == nil {
+			if pos, err := file.Seek(0, io.SeekCurrent); err == nil {
+				reader = io.LimitReader(file, max(end-pos, 0))
+			}
+		}
+	}
+	scanner := newLineScanner(reader)
+	if seeked {
+		// The read starts one byte early, so this is either the rest of a
+		// partial line or the empty remainder of the previous one
+		scanner.Scan()
+	}
+
+	// Track token usage and cost
+	var totals sessionTotals
+
+	if follow && tailBytes > 0 {
+		// --tail-bytes already chose where to start; print everything after
+		for scanner.Scan() {
+			lineNum++
+			printProcessed(renderAt(scanner.Text(), lineAt()), &totals)
+		}
+	} else if follow && lineSpan.set() {
+		// --lines replaces the tail: print the range, then keep following
+		for scanner.Scan() {
+			lineNum++
+			if lineSpan.contains(lineNum) {
+				printProcessed(renderAt(scanner.Text(), lineAt()), &totals)
+			}
+		}
+	} else if follow {
+		// Print tail
+		lines, skipped := readTail(scanner, tail)
+		lineNum = skipped
+		for _, line := range lines {
+			lineNum++
+			printProcessed(renderAt(line, lineAt()), &totals)
+		}
+	} else {
+		// Dump mode: process line-by-line as we read. Reversed dumps render
+		// in file order, so tool pairing still works, and print at the end
+		var rendered []ProcessedLine
+		for scanner.Scan() {
+			lineNum++
+			if lineSpan.past(lineNum) {
+				break
+			}
+			if !lineSpan.contains(lineNum) {
+				continue
+			}
+			result := renderAt(scanner.Text(), lineAt())
+			if reverseMode {
+				rendered = append(rendered, result)
+				continue
+			}
+			if replay != nil {
+				time.Sleep(replay.delay(result))
+			}
+			printProcessed(result, &totals)
+			if outputCapped {
+				break
+			}
+		}
+		if held := heldCalls(); held.Output != "" {
+			if reverseMode {
+				rendered = append(rendered, held)
+			} else {
+				printProcessed(held, &totals)
+			}
+		}
+		for i := len(rendered) - 1; i >= 0 && !outputCapped; i-- {
+			printProcessed(rendered[i], &totals)
+		}
+		finishOutput()
+		// Show total when dumping
+		totals.printSummary()
+		printStreamFooter()
+		return
+	}
+
+	if outputCapped {
+		return
+	}
+
+	// Follow mode: block on filesystem notifications when available,
+	// falling back to polling if the watcher can't be created
+	watcher, err := newFileWatcher(filepath)
+	if err != nil {
+		watcher = nil
+	}
+	defer func() {
+		if watcher != nil {
+			watcher.Close()
+		}
+	}()
+
+	// Keep a running total visible while following
+	status := &statusLine{tty: isTerminal(os.Stdout)}
+	status.show(&totals)
+	status.exitOnInterrupt(&totals)
+	defer status.clear()
+
+	// One reader for the whole follow, so nothing it has buffered is lost
+	// between reads
+	lines := newLineReader(file)
+
+	// reopen switches to reading path from the top, announcing it with banner
+	reopen := func(path, banner string) bool {
+		reopened, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sError reopening file: %v%s\n", red, err, reset)
+			return false
+		}
+		file.Close()
+		file = reopened
+		lines.reset(file)
+		filepath = path
+		lineNum = 0
+		numbered = true
+		if watcher != nil {
+			watcher.Close()
+		}
+		if watcher, err = newFileWatcher(path); err != nil {
+			watcher = nil
+		}
+		status.mu.Lock()
+		status.clear()
+		fmt.Fprintf(stdout(), "\n%s%s%s\n", dim, banner, reset)
+		flushStdout()
+		status.mu.Unlock()
+		return true
+	}
+
+	var lastDirCheck time.Time
+	for {
+		line, err := lines.next()
+		if err == io.EOF {
+			// Caught up: print any run --dedupe is holding back
+			status.flushDedupe(&totals)
+			status.idle(&totals)
+			if fileReplaced(file, filepath) {
+				// The agent rewrote or rotated its log; start over from the top
+				if !reopen(filepath, "--- file truncated, reopened ---") {
+					return
+				}
+				continue
+			}
+			if watchDirMode && time.Since(lastDirCheck) >= watchTimeout {
+				// A new session in the same directory supersedes this one
+				lastDirCheck = time.Now()
+				if newer := newerSession(filepath); newer != "" {
+					toolPairs.reset()
+					if !reopen(newer, fmt.Sprintf("--- switched to session %s ---", newer[strings.LastIndex(newer, "/")+1:])) {
+						return
+					}
+					continue
+				}
+			}
+			waitForChange(watcher)
+			continue
+		}
+		if err != nil {
+			break
+		}
+		lineNum++
+		if !lineSpan.contains(lineNum) {
+			continue
+		}
+		status.update(renderAt(line, lineAt()), &totals)
+		if outputCapped {
+			return
+		}
+	}
+}
+
+// lineRange is an inclusive range of raw JSONL line numbers from --lines;
+// a zero bound is open
+type lineRange struct {
+	start, end int
+}
+
+// Global --lines range; the zero value selects every line
+var lineSpan lineRange
+
+// set reports whether the range restricts anything
+func (r lineRange) set() bool {
+	return r.start > 0 || r.end > 0
+}
+
+// contains reports whether line n falls within the range
+func (r lineRange) contains(n int) bool {
+	return (r.start == 0 || n >= r.start) && (r.end == 0 || n <= r.end)
+}
+
+// past reports whether line n is beyond the end of the range
+func (r lineRange) past(n int) bool {
+	return r.end > 0 && n > r.end
+}
+
+// parseLineRange parses START:END, START:, or :END (1-based, inclusive).
+// A bare number selects that single line.
+func parseLineRange(s string) (lineRange, error) {
+	bound := func(v string) (int, error) {
+		if v = strings.TrimSpace(v); v == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid line number %q", v)
+		}
+		return n, nil
+	}
+	startText, endText, found := strings.Cut(s, ":")
+	if !found {
+		endText = startText
+	}
+	start, err := bound(startText)
+	if err != nil {
+		return lineRange{}, err
+	}
+	end, err := bound(endText)
+	if err != nil {
+		return lineRange{}, err
+	}
+	if end > 0 && start > end {
+		return lineRange{}, fmt.Errorf("start %d is after end %d", start, end)
+	}
+	return lineRange{start, end}, nil
+}
+
+// Global --tail-bytes: start reading this many bytes from the end of the
+// file instead of showing the last n messages
+var tailBytes int64
+
+// completeEnd returns the offset just past the last newline in file, or 0
+// if it has none, without moving the read position
+func completeEnd(file *os.File) (int64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 4096)
+	for end := info.Size(); end > 0; {
+		start := max(end-int64(len(buf)), 0)
+		n, err := file.ReadAt(buf[:end-start], start)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			return start + int64(i) + 1, nil
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+// seekTailBytes positions file to read roughly its last n bytes. It seeks
+// one byte early so the caller can always discard the first line read: that
+// is either a partial line or, if the seek landed on a line boundary, just
+// the previous newline. It reports false if the file is smaller than n.
+func seekTailBytes(file *os.File, n int64) (bool, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	if info.Size() <= n {
+		return false, nil
+	}
+	_, err = file.Seek(info.Size()-n-1, io.SeekStart)
+	return err == nil, err
+}
+
+// Global flag: in follow mode, switch to newer sessions as they appear in
+// the followed file's directory
+var watchDirMode bool
+
+// newerSession returns the newest session of path's agent if it isn't path
+// itself, or "" if path is still the latest. With --glob, the agent's
+// sessions are the ones the template matches; otherwise they're the
+// sessions in path's directory.
+func newerSession(path string) string {
+	var sessions []SessionFile
+	if agent, ok := globAgent(sessionGlob, path); sessionGlob != "" && ok {
+		sessions = getSessions("", agent)
+	} else {
+		sessions = sessionsIn(filepath.Dir(path))
+	}
+	if len(sessions) == 0 || sessions[0].Path == path {
+		return ""
+	}
+	if current, err := os.Stat(path); err == nil && !sessions[0].ModTime.After(current.ModTime()) {
+		return ""
+	}
+	return sessions[0].Path
+}
+
+// readTail consumes the scanner and returns its last n lines, keeping only
+// a ring buffer of size n so memory stays bounded regardless of file size
+func readTail(scanner *bufio.Scanner, n int) (lines []string, skipped int) {
+	count := 0
+	if n <= 0 {
+		for scanner.Scan() {
+			count++
+		}
+		return nil, count
+	}
+	ring := make([]string, n)
+	for scanner.Scan() {
+		ring[count%n] = scanner.Text()
+		count++
+	}
+	if count <= n {
+		return ring[:count], 0
+	}
+	start := count % n
+	return append(ring[start:], ring[:start]...), count - n
+}
+
+// sessionTotals tracks cumulative token usage and cost across a session
+type sessionTotals struct {
+	Tokens     int
+	Output     int
+	CacheRead  int
+	CacheWrite int
+	Cost       float64
+	ByModel    map[string]*modelTotals
+	// Skipped counts entries that produced no output, by reason
+	Skipped map[string]int
+	// OverBudget is set once Cost passes --budget
+	OverBudget bool
+	// First and Last are the earliest and latest entry timestamps seen
+	First time.Time
+	Last  time.Time
+	// Messages counts entries that produced output
+	Messages int
+	// Estimated is set once any of Cost came from --price
+	Estimated bool
+	// OutputSeries holds each assistant message's output tokens, in order,
+	// for --sparkline
+	OutputSeries []int
+}
+
+// Global spend threshold in dollars from --budget; 0 disables it
+var costBudget float64
+
+// budgetWarning describes spend past the --budget threshold
+func budgetWarning(cost float64) string {
+	return fmt.Sprintf("⚠ Budget exceeded: %s spent of %s", formatCost(cost), formatCost(costBudget))
+}
+
+// modelTotals tracks usage for a single model within a session
+type modelTotals struct {
+	Messages int
+	Tokens   int
+	Output   int
+	Cost     float64
+	// Estimated is set once any of Cost came from --price
+	Estimated bool
+}
+
+func (t *sessionTotals) add(usage *Usage) {
+	if usage == nil {
+		return
+	}
+	t.Tokens += usage.TotalTokens
+	t.Output += usage.Output
+	t.CacheRead += usage.CacheRead
+	t.CacheWrite += usage.CacheWrite
+	if usage.Cost != nil {
+		t.Cost += usage.Cost.Total
+		t.Estimated = t.Estimated || usage.Cost.Estimated
+	}
+}
+
+// addModel records usage against the model that produced it
+func (t *sessionTotals) addModel(model string, usage *Usage) {
+	if usage == nil {
+		return
+	}
+	if model == "" {
+		model = "unknown"
+	}
+	if t.ByModel == nil {
+		t.ByModel = make(map[string]*modelTotals)
+	}
+	m, ok := t.ByModel[model]
+	if !ok {
+		m = &modelTotals{}
+		t.ByModel[model] = m
+	}
+	m.Messages++
+	m.Tokens += usage.TotalTokens
+	m.Output += usage.Output
+	if usage.Cost != nil {
+		m.Cost += usage.Cost.Total
+		m.Estimated = m.Estimated || usage.Cost.Estimated
+	}
+}
+
+// sortedModels returns the tracked model names, most expensive first
+func (t *sessionTotals) sortedModels() []string {
+	models := make([]string, 0, len(t.ByModel))
+	for name := range t.ByModel {
+		models = append(models, name)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		a, b := t.ByModel[models[i]], t.ByModel[models[j]]
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		return models[i] < models[j]
+	})
+	return models
+}
+
+// printModelBreakdown prints a per-model table of messages, tokens, and cost,
+// most expensive first
+func (t *sessionTotals) printModelBreakdown() {
+	if len(t.ByModel) == 0 {
+		return
+	}
+	models := t.sortedModels()
+	width := len("model")
+	for _, name := range models {
+		width = max(width, len(name))
+	}
+
+	fmt.Fprintf(stdout(), "\n%sBy model:%s\n", bold, reset)
+	fmt.Fprintf(stdout(), "  %s%-*s  %6s  %8s  %8s  %8s%s\n", dim, width, "model", "msgs", "tokens", "out", "cost", reset)
+	for _, name := range models {
+		m := t.ByModel[name]
+		fmt.Fprintf(stdout(), "  %s%-*s%s  %6d  %8s  %8s  %8s\n", cyan, width, name, reset, m.Messages, formatNumber(m.Tokens), formatNumber(m.Output), approxCost(m.Cost, m.Estimated))
+	}
+}
+
+// see widens the session's time span to include at
+func (t *sessionTotals) see(at time.Time) {
+	if t.First.IsZero() || at.Before(t.First) {
+		t.First = at
+	}
+	if at.After(t.Last) {
+		t.Last = at
+	}
+}
+
+// span returns the wall-clock time from the first to the last timestamped
+// entry, or false if fewer than two distinct times were seen
+func (t *sessionTotals) span() (time.Duration, bool) {
+	if t.First.IsZero() || !t.Last.After(t.First) {
+		return 0, false
+	}
+	return t.Last.Sub(t.First), true
+}
+
+func (t *sessionTotals) empty() bool {
+	return t.Tokens == 0 && t.Output == 0
+}
+
+// String renders the totals as "tokens: N | out: N | cache: N read / N write | $N"
+func (t *sessionTotals) String() string {
+	costStr := ""
+	if t.Cost > 0 {
+		costStr = fmt.Sprintf(" | %s", approxCost(t.Cost, t.Estimated))
+	}
+	return fmt.Sprintf("tokens: %s | out: %s%s%s", formatNumber(t.Tokens), formatNumber(t.Output), cacheText(t.CacheRead, t.CacheWrite), costStr)
+}
+
+func (t *sessionTotals) printSummary() {
+	if quietMode {
+		t.printQuietSummary()
+		return
+	}
+	_, timed := t.span()
+	if t.empty() && len(t.Skipped) == 0 && !timed || isTableFormat(outputFormat) {
+		return
+	}
+	if r := docRendererFor(outputFormat); r != nil {
+		if !t.empty() {
+			fmt.Fprintln(stdout(), r.summary(t))
+		}
+		return
+	}
+	fmt.Fprintf(stdout(), "\n%s%s%s\n", dim, strings.Repeat("─", 60), reset)
+	if !t.empty() {
+		fmt.Fprintf(stdout(), "%sTotal: %s%s\n", dim, t, reset)
+		if t.OverBudget {
+			fmt.Fprintf(stdout(), "%s%s%s%s\n", red, bold, budgetWarning(t.Cost), reset)
+		}
+		if sparklineMode {
+			t.printSparkline()
+		}
+		if byModelMode {
+			t.printModelBreakdown()
+		}
+	}
+	if d, ok := t.span(); ok {
+		fmt.Fprintf(stdout(), "%sDuration: %s (%s)%s\n", dim, formatDuration(d), isoDuration(d), reset)
+	}
+	if len(t.Skipped) > 0 {
+		hint := ""
+		if t.Skipped[skipParseError] > 0 && !showErrorsMode {
+			hint = " (--show-errors for details)"
+		}
+		fmt.Fprintf(stdout(), "%sskipped: %s%s%s\n", dim, t.skippedSummary(), hint, reset)
+	}
+}
+
+// printQuietSummary prints the totals alone for --quiet, even when there
+// was no usage, so a script always gets a line to read
+func (t *sessionTotals) printQuietSummary() {
+	fmt.Fprintf(stdout(), "Total: %s\n", t)
+	if t.OverBudget {
+		fmt.Fprintf(stdout(), "%s%s%s%s\n", red, bold, budgetWarning(t.Cost), reset)
+	}
+	if byModelMode {
+		t.printModelBreakdown()
+	}
+}
+
+// skippedSummary renders skip counts, most common first, as
+// "12 heartbeat, 3 parse errors"
+func (t *sessionTotals) skippedSummary() string {
+	reasons := make([]string, 0, len(t.Skipped))
+	for reason := range t.Skipped {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if t.Skipped[reasons[i]] != t.Skipped[reasons[j]] {
+			return t.Skipped[reasons[i]] > t.Skipped[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	parts := make([]string, len(reasons))
+	for i, reason := range reasons {
+		n := t.Skipped[reason]
+		if reason == skipParseError {
+			reason = plural(n, reason)
+		}
+		parts[i] = fmt.Sprintf("%d %s", n, reason)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// plural returns word, with an "s" unless n is 1
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// statusLine shows the running session totals during follow mode. On a
+// terminal it is redrawn in place with a carriage return; otherwise a plain
+// line is printed after each message that carries usage.
+type statusLine struct {
+	tty     bool
+	visible bool
+	// rate tracks output tokens per second across recent messages
+	rate rateMeter
+	// lastLine is when the last line arrived; idleShown is set once the
+	// --idle-notice for the current quiet spell has been printed
+	lastLine  time.Time
+	idleShown bool
+	// mu keeps the Ctrl-C summary from interleaving with other output
+	mu sync.Mutex
+}
+
+// exitOnInterrupt makes Ctrl-C print the final summary, as a dump would,
+// before exiting
+func (s *statusLine) exitOnInterrupt(totals *sessionTotals) {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		s.finish(totals)
+		os.Exit(0)
+	}()
+}
+
+// finish replaces the status line with the summary printed at the end of a
+// dump. It keeps s locked, so nothing more is printed through it.
+func (s *statusLine) finish(totals *sessionTotals) {
+	s.mu.Lock()
+	s.clear()
+	finishOutput()
+	totals.printSummary()
+	printStreamFooter()
+}
+
+// Global quiet time after which follow mode prints an idle notice; 0
+// disables it
+var idleNotice time.Duration
+
+// idle prints a "--- idle 30s ---" notice once the session has been quiet
+// for idleNotice, then stays silent until the next line arrives
+func (s *statusLine) idle(totals *sessionTotals) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if idleNotice <= 0 || s.idleShown || outputFormat != formatText {
+		return
+	}
+	if s.lastLine.IsZero() {
+		s.lastLine = time.Now()
+		return
+	}
+	quiet := time.Since(s.lastLine)
+	if quiet < idleNotice {
+		return
+	}
+	s.clear()
+	fmt.Fprintf(stdout(), "\n%s--- idle %s ---%s\n", dim, formatDuration(quiet), reset)
+	s.idleShown = true
+	s.show(totals)
+}
+
+// clear erases the in-place status line so regular output can be printed
+func (s *statusLine) clear() {
+	if s.visible {
+		fmt.Fprint(stdout(), "\r\033[K")
+		s.visible = false
+	}
+}
+
+func (s *statusLine) show(totals *sessionTotals) {
+	defer flushStdout()
+	if totals.empty() || outputFormat != formatText {
+		return
+	}
+	text := fmt.Sprintf("%sRunning: %s%s%s", dim, totals, s.rate.rateText(), reset)
+	if totals.OverBudget {
+		// Keep the budget warning in view for the rest of the session
+		text = fmt.Sprintf("%s%s⚠ over budget%s %s", red, bold, reset, text)
+	}
+	if !s.tty {
+		fmt.Fprintln(stdout(), text)
+		return
+	}
+	fmt.Fprint(stdout(), "\r\033[K" + text)
+	s.visible = true
+}
+
+// flushDedupe prints the run held by --dedupe above the status line
+func (s *statusLine) flushDedupe(totals *sessionTotals) {
+	if dedupe == nil || dedupe.count == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clear()
+	flushDedupe()
+	s.show(totals)
+}
+
+// update prints a processed line in follow mode, keeping the status line
+// below the latest output
+func (s *statusLine) update(result ProcessedLine, totals *sessionTotals) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer flushStdout()
+	s.lastLine, s.idleShown = time.Now(), false
+	if result.Output != "" {
+		s.clear()
+	}
+	printProcessed(result, totals)
+	if result.Usage != nil && result.HasTimestamp {
+		s.rate.add(result.Timestamp, result.Usage.Output)
+	}
+	if result.Usage != nil || (s.tty && result.Output != "") {
+		s.show(totals)
+	}
+}
+
+// isTerminal reports whether f is attached to a character device (a TTY)
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Global --max-lines cap on emitted output lines; 0 is unlimited
+var maxLines int
+
+// Global --head limit on printed messages; 0 is unlimited
+var headLimit int
+
+// Global flag: print nothing but the final totals, for cost reports
+var quietMode bool
+
+// linesEmitted counts output lines printed so far and messagesEmitted the
+// messages; outputCapped is set once --max-lines or --head is reached and
+// nothing more will print
+var (
+	linesEmitted    int
+	messagesEmitted int
+	outputCapped    bool
+)
+
+// outputProduced is set once any message passes the filters, whether or not
+// it printed
+var outputProduced bool
+
+// Global flag: exit 0 even when nothing matched
+var exitZero bool
+
+// filtersActive reports whether any filter that picks messages is set: the
+// tool, model, and thinking filters, --only-text, or a --hide pattern
+func filtersActive() bool {
+	if len(toolFilter) > 0 || len(modelFilter) > 0 || thinkingFilter != "" || onlyTextMode {
+		return true
+	}
+	for _, rule := range hideRules {
+		if rule.reason == skipHidden {
+			return true
 		}
 	}
+	return false
+}
 
-	fmt.Printf("%sStreaming: %s%s%s\n", yellow, basename, agentName, reset)
-	fmt.Printf("%s%s%s\n\n", dim, strings.Repeat("─", 60), reset)
-
-	file, err := os.Open(filepath)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%sError opening file: %v%s\n", red, err, reset)
+// exitIfNoOutput ends a finished stream with status 1 when filters are set
+// and no message passed them, like grep, unless --exit-zero is set. An
+// unfiltered stream that prints nothing still succeeds.
+func exitIfNoOutput() {
+	flushStdout()
+	if filtersActive() && !outputProduced && !exitZero {
 		os.Exit(1)
 	}
-	defer file.Close()
+}
 
-	// Read all existing lines
-	scanner := bufio.NewScanner(file)
-	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024) // up to 10MB per line
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// stdoutBuf buffers what a stream prints so a dump isn't a write per line.
+// It's rebound whenever os.Stdout changes, since --output, the pager, and
+// tests swap it.
+var (
+	stdoutBuf  *bufio.Writer
+	stdoutFile *os.File
+)
+
+// stdout returns the buffered writer for os.Stdout
+func stdout() *bufio.Writer {
+	if stdoutBuf == nil || stdoutFile != os.Stdout {
+		flushStdout()
+		stdoutBuf, stdoutFile = bufio.NewWriterSize(os.Stdout, 64*1024), os.Stdout
 	}
+	return stdoutBuf
+}
 
-	// Track token usage and cost
-	var totalContext, totalOutput int
-	var totalCost float64
+// flushStdout writes out whatever stdout is holding. Dumps flush when they
+// end, and follow mode after every line.
+func flushStdout() {
+	if stdoutBuf != nil {
+		stdoutBuf.Flush()
+	}
+}
 
-	// Print tail
-	start := 0
-	if follow && len(lines) > tail {
-		start = len(lines) - tail
+// emit prints rendered output, stopping with a notice once --max-lines
+// lines have been printed
+func emit(output string) {
+	if outputCapped || quietMode {
+		return
 	}
-	for _, line := range lines[start:] {
-		result := processLine(line)
-		if result.Output != "" {
-			fmt.Println(result.Output)
-		}
-		if result.Usage != nil {
-			totalContext += result.Usage.TotalTokens
-			totalOutput += result.Usage.Output
-			if result.Usage.Cost != nil {
-				totalCost += result.Usage.Cost.Total
+	if maxLines > 0 {
+		lines := strings.Split(output, "\n")
+		if linesEmitted+len(lines) > maxLines {
+			if room := maxLines - linesEmitted; room > 0 {
+				fmt.Fprintln(stdout(), strings.Join(lines[:room], "\n"))
 			}
+			fmt.Fprintf(stdout(), "%s... (output truncated, use --max-lines 0 for all)%s\n", dim, reset)
+			linesEmitted, outputCapped = maxLines, true
+			return
 		}
+		linesEmitted += len(lines)
 	}
+	fmt.Fprintln(stdout(), output)
+}
 
-	if !follow {
-		// Show total when dumping
-		if totalContext > 0 || totalOutput > 0 {
-			fmt.Printf("\n%s%s%s\n", dim, strings.Repeat("─", 60), reset)
-			costStr := ""
-			if totalCost > 0 {
-				costStr = fmt.Sprintf(" | %s", formatCost(totalCost))
-			}
-			fmt.Printf("%sTotal: ctx: %s | out: %s%s%s\n", dim, formatNumber(totalContext), formatNumber(totalOutput), costStr, reset)
-		}
-		return
+// Global destination for --progress-json updates; nil when disabled
+var progressWriter io.Writer
+
+// progressUpdate is the JSON object written by --progress-json
+type progressUpdate struct {
+	Tokens     int     `json:"tokens"`
+	Output     int     `json:"output"`
+	CacheRead  int     `json:"cache_read"`
+	CacheWrite int     `json:"cache_write"`
+	Cost       float64 `json:"cost"`
+	Messages   int     `json:"messages"`
+	OverBudget bool    `json:"over_budget,omitempty"`
+}
+
+// writeProgress writes the running totals to w as one line of JSON
+func writeProgress(w io.Writer, totals *sessionTotals) {
+	data, err := json.Marshal(progressUpdate{
+		Tokens:     totals.Tokens,
+		Output:     totals.Output,
+		CacheRead:  totals.CacheRead,
+		CacheWrite: totals.CacheWrite,
+		Cost:       totals.Cost,
+		Messages:   totals.Messages,
+		OverBudget: totals.OverBudget,
+	})
+	if err == nil {
+		fmt.Fprintf(w, "%s\n", data)
 	}
+}
 
-	// Follow mode
-	for {
-		line, err := readLine(file)
-		if err == io.EOF {
-			time.Sleep(300 * time.Millisecond)
-			continue
-		}
-		if err != nil {
-			break
+// printProcessed prints a processed line and adds its usage to the totals
+func printProcessed(result ProcessedLine, totals *sessionTotals) {
+	if groupByTurnMode && outputFormat == formatText && result.Role == "user" && result.Skipped == "" && result.Output != "" {
+		// Anything held belongs to the previous turn
+		flushDedupe()
+		startTurn()
+	}
+	output := result.Output
+	if dedupe != nil {
+		output = dedupe.add(output, result.dedupeKey)
+	}
+	if output != "" && !outputCapped {
+		emit(output)
+		messagesEmitted++
+		if headLimit > 0 && messagesEmitted >= headLimit {
+			outputCapped = true
 		}
-		result := processLine(line)
-		if result.Output != "" {
-			fmt.Println(result.Output)
+	}
+	totals.add(result.Usage)
+	totals.addModel(result.Model, result.Usage)
+	if result.HasTimestamp {
+		totals.see(result.Timestamp)
+	}
+	if result.Output != "" {
+		totals.Messages++
+		outputProduced = true
+	}
+	if sparklineMode && result.Role == "assistant" && result.Usage != nil {
+		totals.OutputSeries = append(totals.OutputSeries, result.Usage.Output)
+	}
+	if progressWriter != nil && (result.Output != "" || result.Usage != nil) {
+		writeProgress(progressWriter, totals)
+	}
+	if costBudget > 0 && !totals.OverBudget && totals.Cost > costBudget {
+		totals.OverBudget = true
+		if outputFormat == formatText && !quietMode {
+			fmt.Fprintf(stdout(), "\n%s%s%s%s\n", red, bold, budgetWarning(totals.Cost), reset)
 		}
-		if result.Usage != nil {
-			totalContext += result.Usage.TotalTokens
-			totalOutput += result.Usage.Output
-			if result.Usage.Cost != nil {
-				totalCost += result.Usage.Cost.Total
-			}
+	}
+	if result.Skipped != "" && result.Skipped != skipCollapsed {
+		if totals.Skipped == nil {
+			totals.Skipped = make(map[string]int)
 		}
+		totals.Skipped[result.Skipped]++
 	}
 }
 
-func readLine(r io.Reader) (string, error) {
-	scanner := bufio.NewScanner(r)
-	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
-	if scanner.Scan() {
-		return scanner.Text(), nil
+// fileReplaced reports whether the file at path has shrunk below our current
+// read offset (truncation) or is no longer the file we have open (rotation)
+func fileReplaced(file *os.File, path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
 	}
-	if err := scanner.Err(); err != nil {
+	if current, err := file.Stat(); err == nil && !os.SameFile(current, info) {
+		return true
+	}
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false
+	}
+	return info.Size() < offset
+}
+
+// lineReader reads lines from a file that is still being written. A line
+// whose newline hasn't arrived yet is held, not returned, so a write that
+// lands in pieces is still read as one line.
+type lineReader struct {
+	r       *bufio.Reader
+	partial string
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// next returns the next complete line without its line ending, or io.EOF
+// when there isn't one yet
+func (l *lineReader) next() (string, error) {
+	chunk, err := l.r.ReadString('\n')
+	l.partial += chunk
+	if err != nil {
 		return "", err
 	}
-	return "", io.EOF
+	line := l.partial
+	l.partial = ""
+	return strings.TrimRight(line, "\r\n"), nil
 }
 
-func listAgents() {
-	agents := getAgents()
+// reset switches to reading r, dropping anything held from the old file
+func (l *lineReader) reset(r io.Reader) {
+	l.r.Reset(r)
+	l.partial = ""
+}
+
+func listAgents(stateDir string) {
+	agents := getAgents(stateDir)
 	if len(agents) == 0 {
-		fmt.Fprintf(os.Stderr, "%sNo agents found in %s%s\n", red, getAgentsDir(), reset)
+		fmt.Fprintf(os.Stderr, "%sNo agents found in %s%s\n", red, getAgentsDir(stateDir), reset)
 		os.Exit(1)
 	}
+	sortAgents(stateDir, agents)
 	fmt.Printf("%sAgents:%s\n\n", bold, reset)
 	for _, agent := range agents {
 		fmt.Printf("  %s%s%s  %s(%d sessions)%s\n", cyan, agent.Name, reset, dim, agent.Count, reset)
 	}
 }
 
-func listSessions(agent string) {
-	sessions := getSessions(agent)
+func listSessions(stateDir, agent string) {
+	sessions := getSessions(stateDir, agent)
 	if len(sessions) == 0 {
 		fmt.Fprintf(os.Stderr, "%sNo sessions for agent '%s'%s\n", red, agent, reset)
 		os.Exit(1)
 	}
 	fmt.Printf("%sSessions for %s%s%s%s:%s\n\n", bold, cyan, agent, reset, bold, reset)
+	printSessionList(os.Stdout, sessions, listLimit)
+	printSessionFooter(os.Stdout, sessions)
+}
+
+// Global flag: count the lines of the latest session when listing
+var withCountsMode bool
 
-	limit := 20
-	if len(sessions) < limit {
+// printSessionFooter prints the number and combined size of all sessions,
+// plus the latest session's line count with --with-counts
+func printSessionFooter(w io.Writer, sessions []SessionFile) {
+	var total int64
+	for _, session := range sessions {
+		if info, err := os.Stat(session.Path); err == nil {
+			total += info.Size()
+		}
+	}
+	fmt.Fprintf(w, "\n  %s%d %s, %s%s\n", dim, len(sessions), plural(len(sessions), "session"), formatBytes(total), reset)
+	if !withCountsMode || len(sessions) == 0 {
+		return
+	}
+	if n, err := countLines(sessions[0].Path); err == nil {
+		fmt.Fprintf(w, "  %slatest: %d %s%s\n", dim, n, plural(n, "line"), reset)
+	}
+}
+
+// countLines returns the number of non-empty lines in a session
+func countLines(path string) (int, error) {
+	file, err := openSession(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	count := 0
+	scanner := newLineScanner(file)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) > 0 {
+			count++
+		}
+	}
+	return count, scanner.Err()
+}
+
+// Sort keys for --sort: sessions sort by name, mtime, or size, and agents
+// by name, count, or mtime
+const (
+	sortName  = "name"
+	sortMtime = "mtime"
+	sortSize  = "size"
+	sortCount = "count"
+)
+
+// Global --sort key for listings; "" keeps each list's default order
+var listSort string
+
+// sessionOrder returns the recency indexes of sessions in --sort order.
+// Times, sizes, and counts sort largest first and names A-Z; --reverse
+// flips either.
+func sessionOrder(sessions []SessionFile) []int {
+	order := make([]int, len(sessions))
+	sizes := make([]int64, len(sessions))
+	for i, session := range sessions {
+		order[i] = i
+		if listSort == sortSize {
+			if info, err := os.Stat(session.Path); err == nil {
+				sizes[i] = info.Size()
+			}
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		switch listSort {
+		case sortName:
+			return filepath.Base(sessions[i].Path) < filepath.Base(sessions[j].Path)
+		case sortSize:
+			return sizes[i] > sizes[j]
+		}
+		// Sessions are already newest first
+		return false
+	})
+	if reverseMode {
+		slices.Reverse(order)
+	}
+	return order
+}
+
+// sortAgents orders agents by --sort: name (the default), session count,
+// or latest session mtime
+func sortAgents(stateDir string, agents []AgentInfo) {
+	latest := make(map[string]time.Time)
+	if listSort == sortMtime {
+		for _, agent := range agents {
+			if sessions := getSessions(stateDir, agent.Name); len(sessions) > 0 {
+				latest[agent.Name] = sessions[0].ModTime
+			}
+		}
+	}
+	sort.SliceStable(agents, func(i, j int) bool {
+		switch listSort {
+		case sortCount:
+			return agents[i].Count > agents[j].Count
+		case sortMtime:
+			return latest[agents[i].Name].After(latest[agents[j].Name])
+		}
+		return agents[i].Name < agents[j].Name
+	})
+	if reverseMode {
+		slices.Reverse(agents)
+	}
+}
+
+// Global number of sessions shown by --list and --pick, from -n; 0 shows
+// them all
+var listLimit = defaultTail
+
+// printSessionList prints up to limit sessions (0 = all) in --sort order,
+// each prefixed with its recency index for --session and --pick
+func printSessionList(w io.Writer, sessions []SessionFile, limit int) {
+	if limit <= 0 || len(sessions) < limit {
 		limit = len(sessions)
 	}
-	for _, session := range sessions[:limit] {
+	for _, i := range sessionOrder(sessions)[:limit] {
+		session := sessions[i]
 		basename := filepath.Base(session.Path)
 		info, _ := os.Stat(session.Path)
-		size := info.Size()
-		sizeStr := fmt.Sprintf("%.0fK", float64(size)/1024)
-		if size >= 1024*1024 {
-			sizeStr = fmt.Sprintf("%.1fM", float64(size)/(1024*1024))
+		sizeStr := formatBytes(info.Size())
+		modTime := session.ModTime
+		if timeZone != nil {
+			modTime = modTime.In(timeZone)
+		}
+		mtime := modTime.Format("2006-01-02 15:04")
+		fmt.Fprintf(w, "  %s%2d%s  %s%s%s  %6s  %s\n", bold, i, reset, dim, mtime, reset, sizeStr, basename)
+	}
+}
+
+// sessionByIndex returns the session at recency index n (0 = latest)
+func sessionByIndex(sessions []SessionFile, n int) (string, error) {
+	if len(sessions) == 0 {
+		return "", fmt.Errorf("no sessions found")
+	}
+	if n < 0 || n >= len(sessions) {
+		return "", fmt.Errorf("session index %d out of range (0-%d)", n, len(sessions)-1)
+	}
+	return sessions[n].Path, nil
+}
+
+// findSession resolves --session: a number within range is a recency
+// index, anything else must prefix exactly one session filename
+func findSession(sessions []SessionFile, ref string) (string, error) {
+	if n, err := strconv.Atoi(ref); err == nil && n >= 0 && n < len(sessions) {
+		return sessions[n].Path, nil
+	}
+
+	var matches []string
+	for _, session := range sessions {
+		if strings.HasPrefix(filepath.Base(session.Path), ref) {
+			matches = append(matches, session.Path)
 		}
-		mtime := session.ModTime.Format("2006-01-02 15:04")
-		fmt.Printf("  %s%s%s  %6s  %s\n", dim, mtime, reset, sizeStr, basename)
 	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no session matches %q", ref)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, path := range matches {
+		names[i] = filepath.Base(path)
+	}
+	return "", fmt.Errorf("session %q is ambiguous: %s", ref, strings.Join(names, ", "))
+}
+
+// pickSession lists recent sessions on stderr and reads an index from in;
+// an empty answer picks the latest
+func pickSession(sessions []SessionFile, in io.Reader) (string, error) {
+	if len(sessions) == 0 {
+		return "", fmt.Errorf("no sessions to pick from")
+	}
+	printSessionList(os.Stderr, sessions, listLimit)
+	fmt.Fprintf(os.Stderr, "\nSession [0]: ")
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && answer == "" {
+		return "", fmt.Errorf("no session picked")
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return sessions[0].Path, nil
+	}
+	n, err := strconv.Atoi(answer)
+	if err != nil {
+		return "", fmt.Errorf("not a session number: %s", answer)
+	}
+	return sessionByIndex(sessions, n)
+}
+
+// Color modes selected with --color
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// useColor decides whether to emit ANSI codes to f: auto colors only
+// terminals, so files and pipes get plain text
+func useColor(mode string, f *os.File) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	return isTerminal(f)
+}
+
+// disableColors clears the ANSI color codes for plain-text output
+func disableColors() {
+	cyan, green, yellow, red, dim, bold, reset, magenta, blue = "", "", "", "", "", "", "", "", ""
+	underline = ""
+	roleColors = nil
+}
+
+// openOutput opens path for --output: "-" means stdout, follow mode appends
+// so an existing capture keeps growing, and dump mode overwrites
+func openOutput(path string, follow bool) (*os.File, error) {
+	if path == "" || path == "-" {
+		return os.Stdout, nil
+	}
+	mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if follow {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	return os.OpenFile(path, mode, 0644)
+}
+
+// flagPassed reports whether any of the named flags was given on the
+// command line, as opposed to holding a default from the config file
+func flagPassed(names ...string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) {
+		if slices.Contains(names, f.Name) {
+			passed = true
+		}
+	})
+	return passed
 }
 
 func main() {
-	agent := flag.String("agent", defaultAgent, "Agent id")
-	flag.StringVar(agent, "a", defaultAgent, "Agent id (shorthand)")
+	// Config file values become the flag defaults, so flags still win
+	cfgPath := os.Getenv("SESSION_STREAM_CONFIG")
+	if cfgPath == "" {
+		cfgPath = configPath()
+	}
+	cfg, err := loadConfig(cfgPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sError reading config %s: %v%s\n", red, cfgPath, err, reset)
+		os.Exit(1)
+	}
+	lastSessionFile = lastSessionPath()
+
+	agent := flag.String("agent", cfg.Agent, "Agent id")
+	flag.StringVar(agent, "a", cfg.Agent, "Agent id (shorthand)")
 	list := flag.Bool("list", false, "List agents or sessions")
+	flag.StringVar(&listSort, "sort", "", "Sort --list output: name, mtime, or size for sessions; name, count, or mtime for agents")
+	flag.BoolVar(&withCountsMode, "with-counts", false, "With --list --agent, count the lines of the latest session")
 	flag.BoolVar(list, "l", false, "List agents or sessions (shorthand)")
 	noFollow := flag.Bool("no-follow", false, "Dump and exit")
-	n := flag.Int("n", defaultTail, "Number of recent messages to show")
+	replayFlag := flag.Bool("replay", false, "Dump a session paced by its original timestamps (pauses capped at 5s)")
+	speed := flag.Float64("speed", 1, "Playback speed for --replay, e.g. 2 for twice as fast")
+	n := flag.Int("n", cfg.Tail, "Number of recent messages to show, or of sessions with --list and --pick (0 = every session)")
+	flag.Int64Var(&tailBytes, "tail-bytes", 0, "Start from the last N bytes of the file instead of the last -n messages")
 	verbose := flag.Bool("verbose", false, "Show request entries (inber format)")
 	flag.BoolVar(verbose, "v", false, "Show request entries (shorthand)")
+	stdin := flag.Bool("stdin", false, "Read JSONL from standard input")
+	checkOrder := flag.Bool("check-order", false, "Warn on stderr when timestamps go backwards or a message has none")
+	showErrors := flag.Bool("show-errors", false, "Warn on stderr about lines that aren't valid JSON")
+	byModel := flag.Bool("by-model", false, "Show a per-model token and cost breakdown in the summary")
+	stats := flag.Bool("stats", false, "Summarize the session without printing messages")
+	full := flag.Bool("full", false, "Show full, untruncated text, tool arguments, and results")
+	flag.BoolVar(&exitZero, "exit-zero", false, "Exit 0 even when no message passed the filters")
+	flag.BoolVar(&showModelMode, "show-model", false, "Name the model on each assistant header, e.g. Agent [claude-sonnet-4]")
+	flag.BoolVar(&sparklineMode, "sparkline", false, "End dumps with a sparkline of output tokens per assistant message")
+	flag.BoolVar(&quietMode, "quiet", false, "Print only the final totals, no messages (implies --no-follow)")
+	flag.IntVar(&headLimit, "head", 0, "Dump only the first N messages of the session and stop (0 = all)")
+	flag.IntVar(&maxLines, "max-lines", 0, "Stop after printing this many output lines (0 = no limit)")
+	fullThinking := flag.Bool("full-thinking", false, "Show thinking text in full while keeping other truncation")
+	flag.IntVar(&maxTextLen, "max-text", cfg.MaxText, "Preview user and thinking text longer than this many bytes (0 = no limit)")
+	flag.IntVar(&maxResultLen, "max-result", cfg.MaxResult, "Truncate tool results to this many bytes (0 = no limit)")
+	flag.IntVar(&maxArgLen, "max-args", cfg.MaxArgs, "Truncate each tool argument value to this many bytes (0 = no limit)")
+	prettyArgsFlag := flag.Bool("pretty-args", false, "Pretty-print tool arguments as indented JSON")
+	lines := flag.String("lines", "", "Only process raw JSONL lines in this inclusive range: START:END, START:, or :END")
+	lineNumbers := flag.Bool("line-numbers", false, "Prefix each message with its source JSONL line number")
+	dedupeFlag := flag.Bool("dedupe", false, "Collapse runs of identical messages into one with a (×N) suffix, ignoring timestamps")
+	dedupeExact := flag.Bool("dedupe-exact", false, "With --dedupe, only collapse messages whose timestamps match too")
+	noThinking := flag.Bool("no-thinking", false, "Hide thinking (reasoning) entries")
+	onlyThinking := flag.Bool("only-thinking", false, "Show only thinking (reasoning) entries")
+	onlyText := flag.Bool("only-text", false, "Show only user, assistant, thinking, and system text, hiding all tool activity")
+	summaryOnly := flag.Bool("summary-only", false, "Reduce each message to one line: time, role, and a short preview")
+	groupByTurn := flag.Bool("group-by-turn", false, "Box each user-initiated turn with a numbered header and a closing rule")
+	compact := flag.Bool("compact", false, "Collapse each tool call and its result into one line (text output)")
+	prettyResults := flag.Bool("pretty-results", false, "Indent and highlight tool results that are JSON (also set by --pretty-args)")
+	noDiff := flag.Bool("no-diff", false, "Show edit tool arguments as a plain summary instead of a colorized diff")
+	width := flag.Int("width", 0, "Wrap message text to this many columns (default: terminal width)")
+	flag.IntVar(&indentWidth, "indent", indentWidth, "Indentation width for message bodies and tool activity")
+	format := flag.String("format", formatText, "Output format: text, markdown, html, or csv/tsv for a per-message usage table")
+	var tools stringList
+	flag.Var(&tools, "tool", "Only show calls and results for this tool (repeatable)")
+	var models stringList
+	flag.Var(&models, "model", "Only show entries from models whose name contains this (repeatable)")
+	strictModel := flag.Bool("strict-model", false, "With --model, also hide entries that have no model (user, system, tool results)")
+	anonymize := flag.Bool("anonymize", false, "Replace home directories with ~ and mask things that look like API keys or tokens")
+	var redactPatterns []string
+	flag.Func("redact-pattern", "Mask text matching this regexp (repeatable)", func(p string) error {
+		redactPatterns = append(redactPatterns, p)
+		return nil
+	})
+	var hide []string
+	flag.Func("hide", "Hide user messages matching this regexp (repeatable)", func(p string) error {
+		hide = append(hide, p)
+		return nil
+	})
+	noHeartbeatTimestamps := flag.Bool("no-heartbeat-timestamps", false, "Hide timestamp-only entries instead of showing them as dim ticks")
+	watchDir := flag.Bool("watch-dir", false, "In follow mode, switch to newer sessions as they are created")
+	flag.Float64Var(&costAlert, "cost-alert", costAlert, "Show per-message costs at or above this many dollars in red")
+	flag.Float64Var(&costBudget, "budget", 0, "Warn when the session's running cost passes this many dollars")
+	var priceSpecs stringList
+	flag.Var(&priceSpecs, "price", "Estimate missing costs: MODEL=IN,OUT[,CACHE_READ,CACHE_WRITE] in $ per 1k tokens, * for any model (repeatable)")
+	currency := flag.String("currency", "USD", "Show costs in this currency (e.g. EUR), converted with --rate")
+	rate := flag.Float64("rate", 0, "Units of --currency per US dollar, for converting costs")
+	progressJSON := flag.Bool("progress-json", false, "Write running totals to stderr as a line of JSON whenever they change")
+	showCache := flag.Bool("show-cache", false, "Show cache read/write tokens on each message")
+	showHeartbeat := flag.Bool("show-heartbeat", false, "Show heartbeat prompts, which are hidden by default")
+	output := flag.String("output", "", "Write rendered output to this file instead of stdout (\"-\" for stdout)")
+	flag.StringVar(output, "o", "", "Write rendered output to this file (shorthand)")
+	color := flag.String("color", cfg.Color, "Colorize output: auto, always, or never")
+	flag.DurationVar(&pollInterval, "poll-interval", defaultPollInterval, "In follow mode, how long to wait between checks when file notifications are unavailable")
+	flag.DurationVar(&idleNotice, "idle-notice", 0, "In follow mode, print a notice after this long without new lines, e.g. 30s (0 = off)")
+	noHighlight := flag.Bool("no-highlight", false, "Don't highlight URLs and file paths in message text")
+	themeName := flag.String("theme", cfg.Theme, "Color theme: dark, light, or mono")
+	colorFlags := make(map[string]*string)
+	for _, role := range colorRoles {
+		colorFlags[role] = flag.String("color-"+role, cfg.Colors.forRole(role), "Color for "+role+" messages: a name like blue or bright-cyan, or 0-255")
+	}
+	reverse := flag.Bool("reverse", false, "With --no-follow, print newest messages first; with --list, flip the --sort order")
+	timeFormatFlag := flag.String("time-format", timeFormat, "Timestamp layout: a Go layout, or rfc3339, datetime, kitchen")
+	relative := flag.Bool("relative", false, "Show timestamps as age (follow mode) or time since the previous message (--no-follow)")
+	tz := flag.String("tz", "", "Render timestamps in this zone: local, UTC, or an IANA name like Europe/Berlin")
+	utc := flag.Bool("utc", false, "Render timestamps in UTC (short for --tz UTC)")
+	formatIn := flag.String("format-in", inputAuto, "Session format: "+normalizerNames()+", or auto to detect each line")
+	flag.StringVar(&sessionGlob, "glob", os.Getenv("OPENCLAW_SESSION_GLOB"), "Session path template with an {agent} placeholder, e.g. '/var/log/agents/{agent}/*.log' (default: $OPENCLAW_SESSION_GLOB or <state-dir>/agents/{agent}/sessions/*.jsonl)")
+	stateDir := flag.String("state-dir", "", "State directory holding agents/ (default: $OPENCLAW_STATE_DIR or ~/.openclaw)")
+	mergeFiles := flag.Bool("merge-files", false, "With several files, interleave them by timestamp instead of one after another")
+	allAgents := flag.Bool("all-agents", false, "Merge the latest session of every agent into one chronological stream")
+	pick := flag.Bool("pick", false, "Choose a recent session interactively")
+	resume := flag.Bool("resume", false, "Reopen the session streamed last")
+	session := flag.String("session", "", "Stream a session by recency index (0 = latest) or filename prefix")
+	count := flag.Bool("count", false, "Print only the number of messages that pass the active filters")
+	search := flag.String("search", "", "Search every session of the agent (or all agents with --all-agents) for this text")
+	context := flag.Int("context", 0, "With --search, also show this many messages before and after each match")
+	pager := flag.Bool("pager", false, "Page long --no-follow output through $PAGER (default: less -R)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Stream OpenClaw and inber session logs in a readable format.\n\n")
@@ -714,10 +3672,81 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  session-stream --agent argraphments   # latest session for a specific agent\n")
 		fmt.Fprintf(os.Stderr, "  session-stream --list                 # list available agents\n")
 		fmt.Fprintf(os.Stderr, "  session-stream --list --agent work    # list sessions for an agent\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --list --agent work --with-counts  # and count the latest's lines\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --list --agent work --sort size    # largest sessions first\n")
+		fmt.Fprintf(os.Stderr, "  session-stream old.jsonl new.jsonl            # rotated files as one stream\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --model opus --strict-model  # exits 1 if nothing matched\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --sparkline        # where the output tokens went\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --price opus=0.015,0.075       # estimate costs the log omits\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --currency EUR --rate 0.92    # costs in euros\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --poll-interval 50ms           # poll faster without file notifications\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --show-model                  # which model wrote each answer\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --utc                         # timestamps in UTC\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --quiet                       # just the Total line, for cost reports\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --resume                      # reopen the session streamed last\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --list --agent work -n 0           # every session, not just 20\n")
 		fmt.Fprintf(os.Stderr, "  session-stream <path>.jsonl           # stream a specific file\n")
+		fmt.Fprintf(os.Stderr, "  session-stream <path>.jsonl.gz        # dump an archived session\n")
+		fmt.Fprintf(os.Stderr, "  session-stream https://host/s.jsonl   # dump a session served over HTTP(S)\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --all-agents           # every agent's latest session, merged\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --time-format datetime --tz UTC  # dated timestamps in UTC\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --relative             # \"12s ago\" timestamps (gaps with --no-follow)\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --state-dir /mnt/backup/.openclaw --list  # read another state dir\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --glob '/var/log/agents/{agent}/*.log'    # custom session layout\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --format-in inber session.jsonl             # don't guess the format\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --search \"rate limit\"  # find sessions mentioning a phrase\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --search panic --context 2  # with 2 messages either side\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --pick                 # choose from recent sessions\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --session 1            # previous session (0 = latest)\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --session 3f2a9c1e     # session whose filename starts with this\n")
 		fmt.Fprintf(os.Stderr, "  session-stream --no-follow            # dump and exit (no tail)\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --watch-dir            # follow new sessions as they start\n")
 		fmt.Fprintf(os.Stderr, "  session-stream -n 50                  # show last N messages instead of default 20\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --tail-bytes 65536     # start 64KB from the end of a huge file\n")
 		fmt.Fprintf(os.Stderr, "  session-stream --verbose              # show request entries (inber format)\n")
+		fmt.Fprintf(os.Stderr, "  cat session.jsonl | session-stream --stdin  # read from a pipe\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --by-model # per-model usage breakdown\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --show-cache           # cache read/write tokens per message\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --budget 5             # warn once the session costs more than $5\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --cost-alert 0.5       # only turns costing $0.50+ show in red\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --count --tool shell   # just the number of matching messages\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --progress-json 2>progress.jsonl  # running totals as JSON on stderr\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --stats                # message, tool, and token counts only\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --show-errors  # report lines that aren't valid JSON\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --check-order  # report timestamps that go backwards\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --tool shell --tool exec  # only show these tools' activity\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --model haiku          # only entries from models matching this\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-thinking          # hide reasoning (--only-thinking for just it)\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --only-text            # just the conversation, no tool activity\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --dedupe               # collapse repeated messages with (×N)\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --summary-only         # one line per message\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --group-by-turn        # numbered box around each exchange\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --compact              # one line per tool call and result\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --line-numbers         # prefix messages with their JSONL line\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --lines 100:200 --no-follow  # replay a slice of raw lines\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --full                 # disable all truncation\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --full-thinking        # untruncated reasoning, truncated tool output\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --hide '^/status'      # hide user messages matching a regexp\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --show-heartbeat       # show heartbeat prompts too\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-heartbeat-timestamps  # hide timestamp-only ticks\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --max-lines 500  # stop a runaway dump\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --head 5                     # how the session started\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --max-text 2000 --max-result 1000  # tune truncation limits\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --pretty-args          # one tool argument per line\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --pretty-results       # indent and highlight JSON tool results\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --format markdown > session.md  # export for sharing\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --format html > session.html    # self-contained page\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --anonymize --format markdown  # scrub before sharing\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --theme light                               # readable on light backgrounds\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --replay --speed 4                          # replay at 4x the original pace\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --idle-notice 30s                           # note quiet spells while following\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-highlight                              # plain URLs and paths\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --color-assistant blue --color-tool cyan   # per-role colors\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --format csv > usage.csv       # per-message usage table\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --output session.log   # write to a file (plain text unless --color always)\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --pager    # page long sessions through less -R\n")
+		fmt.Fprintf(os.Stderr, "  session-stream --no-follow --reverse  # newest messages first\n")
+		fmt.Fprintf(os.Stderr, "\nDefaults for agent, n, color, and the max-* limits can be set in\n%s\n", configPath())
 		fmt.Fprintf(os.Stderr, "\nOptions:\n")
 		flag.PrintDefaults()
 	}
@@ -726,26 +3755,316 @@ func main() {
 	
 	// Set global verbose flag
 	verboseMode = *verbose
+	byModelMode = *byModel
+	fullMode = *full
+	fullThinkingMode = *fullThinking
+	prettyArgsMode = *prettyArgsFlag
+	prettyResultsMode = *prettyResults || prettyArgsMode
+	noDiffMode = *noDiff
+	lineNumbersMode = *lineNumbers
+	compactMode = *compact
+	groupByTurnMode = *groupByTurn
+	summaryOnlyMode = *summaryOnly
+	onlyTextMode = *onlyText
+	switch {
+	case *noThinking && *onlyThinking:
+		fmt.Fprintf(os.Stderr, "%s--no-thinking and --only-thinking can't be combined%s\n", red, reset)
+		os.Exit(1)
+	case *noThinking:
+		thinkingFilter = thinkingHide
+	case *onlyThinking:
+		thinkingFilter = thinkingOnly
+	}
+	if *dedupeFlag {
+		dedupe = &deduper{exact: *dedupeExact}
+	}
+	if *lines != "" {
+		if tailBytes > 0 {
+			fmt.Fprintf(os.Stderr, "%s--lines can't be combined with --tail-bytes%s\n", red, reset)
+			os.Exit(1)
+		}
+		span, err := parseLineRange(*lines)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sInvalid --lines range: %v%s\n", red, err, reset)
+			os.Exit(1)
+		}
+		lineSpan = span
+	}
+	reverseMode = *reverse
+	listLimit = *n
+	if headLimit > 0 || quietMode {
+		// The first messages and the final totals are only known from a dump
+		*noFollow = true
+	}
+	if *replayFlag {
+		if *reverse {
+			fmt.Fprintf(os.Stderr, "%s--replay cannot be combined with --reverse%s\n", red, reset)
+			os.Exit(1)
+		}
+		if *speed <= 0 {
+			fmt.Fprintf(os.Stderr, "%sInvalid --speed: must be greater than 0%s\n", red, reset)
+			os.Exit(1)
+		}
+		// A replay is a paced dump
+		*noFollow = true
+		replay = &replayer{speed: *speed}
+	}
+	showErrorsMode = *showErrors
+	if *checkOrder {
+		orderCheck = &orderChecker{}
+	}
+	showCacheMode = *showCache
+	highlightMode = !*noHighlight
+	if *progressJSON {
+		progressWriter = os.Stderr
+	}
+	watchDirMode = *watchDir
+	heartbeatTimestamps = !*noHeartbeatTimestamps
+	timeFormat = parseTimeFormat(*timeFormatFlag)
+	if *relative {
+		relativeMode = relativeNow
+		if *noFollow {
+			relativeMode = relativePrevious
+		}
+	}
+	if *utc {
+		if *tz != "" && !strings.EqualFold(*tz, "UTC") {
+			fmt.Fprintf(os.Stderr, "%s--utc cannot be combined with --tz %s%s\n", red, *tz, reset)
+			os.Exit(1)
+		}
+		*tz = "UTC"
+	}
+	if *tz != "" {
+		loc, err := parseTimeZone(*tz)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sUnknown time zone: %s%s\n", red, *tz, reset)
+			os.Exit(1)
+		}
+		timeZone = loc
+	}
+	switch *format {
+	case formatText:
+		outputFormat = formatText
+	case formatMarkdown, "md":
+		outputFormat = formatMarkdown
+	case formatHTML:
+		outputFormat = formatHTML
+	case formatCSV, formatTSV:
+		outputFormat = *format
+	default:
+		fmt.Fprintf(os.Stderr, "%sUnknown format: %s (expected text, markdown, html, csv, or tsv)%s\n", red, *format, reset)
+		os.Exit(1)
+	}
+	for _, spec := range priceSpecs {
+		p, err := parsePrice(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sInvalid --price: %v%s\n", red, err, reset)
+			os.Exit(1)
+		}
+		prices = append(prices, p)
+	}
+	if *currency != "USD" || *rate != 0 {
+		if *rate == 0 && strings.ToUpper(*currency) != "USD" {
+			fmt.Fprintf(os.Stderr, "%s--currency %s needs --rate, the units per US dollar%s\n", red, *currency, reset)
+			os.Exit(1)
+		}
+		if *rate == 0 {
+			*rate = 1
+		}
+		if err := setCurrency(*currency, *rate); err != nil {
+			fmt.Fprintf(os.Stderr, "%sInvalid --currency: %v%s\n", red, err, reset)
+			os.Exit(1)
+		}
+	}
+	if err := checkPollInterval(pollInterval); err != nil {
+		fmt.Fprintf(os.Stderr, "%sInvalid --poll-interval: %v%s\n", red, err, reset)
+		os.Exit(1)
+	}
+	if quietMode && outputFormat != formatText {
+		fmt.Fprintf(os.Stderr, "%s--quiet prints a text summary and cannot be combined with --format %s%s\n", red, outputFormat, reset)
+		os.Exit(1)
+	}
+	if _, ok := lookupNormalizer(*formatIn); !ok && *formatIn != inputAuto {
+		fmt.Fprintf(os.Stderr, "%sUnknown input format: %s (expected %s, or auto)%s\n", red, *formatIn, normalizerNames(), reset)
+		os.Exit(1)
+	}
+	inputFormat = *formatIn
+	switch *color {
+	case colorAuto, colorAlways, colorNever:
+	default:
+		fmt.Fprintf(os.Stderr, "%sUnknown color mode: %s (expected auto, always, or never)%s\n", red, *color, reset)
+		os.Exit(1)
+	}
+	out, err := openOutput(*output, !*noFollow && !*stdin && !*stats && !*list && !*count)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sError opening output: %v%s\n", red, err, reset)
+		os.Exit(1)
+	}
+	if out != os.Stdout {
+		// Everything prints through fmt to os.Stdout, so redirect it
+		defer out.Close()
+		os.Stdout = out
+	}
+	if err := applyTheme(*themeName); err != nil {
+		fmt.Fprintf(os.Stderr, "%sInvalid --theme: %v%s\n", red, err, reset)
+		os.Exit(1)
+	}
+	for _, role := range colorRoles {
+		if *colorFlags[role] == "" {
+			continue
+		}
+		if err := setRoleColor(role, *colorFlags[role]); err != nil {
+			fmt.Fprintf(os.Stderr, "%sInvalid --color-%s: %v%s\n", red, role, err, reset)
+			os.Exit(1)
+		}
+	}
+	if !useColor(*color, os.Stdout) {
+		disableColors()
+	}
+	wrapWidth = *width
+	if wrapWidth == 0 && isTerminal(os.Stdout) && outputFormat == formatText {
+		wrapWidth = terminalWidth(os.Stdout)
+	}
+	if err := setHideRules(hide, *showHeartbeat); err != nil {
+		fmt.Fprintf(os.Stderr, "%sInvalid --hide pattern: %v%s\n", red, err, reset)
+		os.Exit(1)
+	}
+	if err := setRedactRules(*anonymize, redactPatterns); err != nil {
+		fmt.Fprintf(os.Stderr, "%sInvalid --redact-pattern: %v%s\n", red, err, reset)
+		os.Exit(1)
+	}
+	if len(tools) > 0 {
+		toolFilter = make(map[string]bool)
+		for _, name := range tools {
+			toolFilter[strings.ToLower(name)] = true
+		}
+	}
+
+	modelFilter = models
+	modelStrict = *strictModel
+
+	if *stateDir == "" {
+		*stateDir = getStateDir()
+	}
+
+	if *stdin {
+		if *count {
+			n, err := countMessages(os.Stdin)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%sError reading stdin: %v%s\n", red, err, reset)
+				os.Exit(1)
+			}
+			fmt.Println(n)
+			return
+		}
+		streamStdin()
+		exitIfNoOutput()
+		return
+	}
 
 	if *list {
-		if *agent != defaultAgent {
-			listSessions(*agent)
+		// A configured agent doesn't count: only --agent asks for sessions
+		if flagPassed("agent", "a") {
+			if listSort != "" && listSort != sortName && listSort != sortMtime && listSort != sortSize {
+				fmt.Fprintf(os.Stderr, "%sUnknown --sort for sessions: %s (expected name, mtime, or size)%s\n", red, listSort, reset)
+				os.Exit(1)
+			}
+			listSessions(*stateDir, *agent)
 		} else {
-			listAgents()
+			if listSort != "" && listSort != sortName && listSort != sortCount && listSort != sortMtime {
+				fmt.Fprintf(os.Stderr, "%sUnknown --sort for agents: %s (expected name, count, or mtime)%s\n", red, listSort, reset)
+				os.Exit(1)
+			}
+			listAgents(*stateDir)
+		}
+		return
+	}
+
+	if *search != "" {
+		agents := []string{*agent}
+		if *allAgents {
+			agents = nil
+			for _, a := range getAgents(*stateDir) {
+				agents = append(agents, a.Name)
+			}
+		}
+		searchSessions(*stateDir, agents, *search, *context)
+		return
+	}
+
+	if *allAgents {
+		streamAllAgents(*stateDir, !*noFollow, *n)
+		exitIfNoOutput()
+		return
+	}
+
+	if flag.NArg() > 1 {
+		if *stats || *count {
+			fmt.Fprintf(os.Stderr, "%s--stats and --count take a single file%s\n", red, reset)
+			os.Exit(1)
 		}
+		for _, path := range flag.Args() {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "%sFile not found: %s%s\n", red, path, reset)
+				os.Exit(1)
+			}
+		}
+		streamFiles(flag.Args(), !*noFollow, *n, *mergeFiles)
+		exitIfNoOutput()
 		return
 	}
 
 	filepath := ""
 	if flag.NArg() > 0 {
 		filepath = flag.Arg(0)
-		if _, err := os.Stat(filepath); os.IsNotExist(err) {
+		if _, err := os.Stat(filepath); os.IsNotExist(err) && !isURL(filepath) {
 			fmt.Fprintf(os.Stderr, "%sFile not found: %s%s\n", red, filepath, reset)
 			os.Exit(1)
 		}
+	} else if *resume {
+		if filepath, err = loadLastSession(); err != nil {
+			fmt.Fprintf(os.Stderr, "%sCan't resume: %v%s\n", red, err, reset)
+			os.Exit(1)
+		}
+		if _, err := os.Stat(filepath); os.IsNotExist(err) && !isURL(filepath) {
+			fmt.Fprintf(os.Stderr, "%sLast session no longer exists: %s%s\n", red, filepath, reset)
+			os.Exit(1)
+		}
+	} else if *pick || *session != "" {
+		sessions := getSessions(*stateDir, *agent)
+		if *pick {
+			filepath, err = pickSession(sessions, os.Stdin)
+		} else {
+			filepath, err = findSession(sessions, *session)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s%v%s\n", red, err, reset)
+			os.Exit(1)
+		}
 	} else {
-		filepath = findLatestSession(*agent)
+		filepath = findLatestSession(*stateDir, *agent)
+	}
+
+	if *count {
+		countFile(filepath)
+		return
+	}
+
+	render := func() {
+		if *stats {
+			showStats(filepath)
+			return
+		}
+		streamFile(filepath, !*noFollow, *n)
 	}
 
-	streamFile(filepath, !*noFollow, *n)
+	// Follow mode never ends, so only finished output can be paged
+	if *pager && (*noFollow || *stats) && replay == nil && isTerminal(os.Stdout) {
+		paged(render)
+	} else {
+		render()
+	}
+	if !*stats {
+		exitIfNoOutput()
+	}
 }
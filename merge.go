@@ -0,0 +1,321 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// agentSession is the latest session file of one agent
+type agentSession struct {
+	Agent string
+	Path  string
+}
+
+// agentLine is a raw JSONL line tagged with the agent that wrote it
+type agentLine struct {
+	Agent string
+	Line  string
+	At    time.Time
+}
+
+// latestSessions returns the newest session of every agent that has one
+func latestSessions(stateDir string) []agentSession {
+	var sources []agentSession
+	for _, agent := range getAgents(stateDir) {
+		if sessions := getSessions(stateDir, agent.Name); len(sessions) > 0 {
+			sources = append(sources, agentSession{Agent: agent.Name, Path: sessions[0].Path})
+		}
+	}
+	return sources
+}
+
+// lineTime returns the timestamp of a JSONL line, if it has one
+func lineTime(line string) (time.Time, bool) {
+	var entry LogEntry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return time.Time{}, false
+	}
+	_, _, _, ts := normalizeEntry(&entry)
+	return parseTimestamp(ts)
+}
+
+// readCompleteLines reads the complete lines of a session file, returning
+// the offset just past the last newline so a follower can resume there
+// without splitting a line that is still being written. Gzip archives are
+// read whole and report an offset of -1, since there's nothing to follow.
+func readCompleteLines(path string) ([]string, int64, error) {
+	file, err := openSession(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	end := strings.LastIndexByte(string(data), '\n') + 1
+	offset := int64(end)
+	if isGzip(path) {
+		end, offset = len(data), -1
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data[:end]), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, offset, nil
+}
+
+// mergeLines interleaves each agent's lines chronologically. Lines without a
+// timestamp take the previous line's so they stay with their neighbours, and
+// the sort is stable so ties keep agent order, then file order.
+func mergeLines(sources [][]agentLine) []agentLine {
+	var merged []agentLine
+	for _, lines := range sources {
+		var last time.Time
+		for _, l := range lines {
+			if l.At.IsZero() {
+				l.At = last
+			}
+			last = l.At
+			merged = append(merged, l)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].At.Before(merged[j].At)
+	})
+	return merged
+}
+
+// agentMerger renders merged lines, keeping tool pairing per agent and
+// labeling output with the agent it came from. With concat set, the lines
+// are one session split across files: tool pairing is shared and a banner
+// marks each new file instead of a per-line label.
+type agentMerger struct {
+	trackers  map[string]*toolTracker
+	lastAgent string
+	concat    bool
+}
+
+func (m *agentMerger) render(l agentLine) ProcessedLine {
+	if m.concat {
+		return m.renderConcat(l)
+	}
+	if m.trackers == nil {
+		m.trackers = make(map[string]*toolTracker)
+	}
+	if m.trackers[l.Agent] == nil {
+		m.trackers[l.Agent] = &toolTracker{}
+	}
+	saved := toolPairs
+	toolPairs = m.trackers[l.Agent]
+	result := renderAt(l.Line, 0)
+	toolPairs = saved
+
+	if result.Output == "" {
+		return result
+	}
+	if result.dedupeKey != "" {
+		// Identical lines from different agents aren't repeats
+		result.dedupeKey = l.Agent + "\x00" + result.dedupeKey
+	}
+	if r := docRendererFor(outputFormat); r != nil {
+		// Documents can't take a per-line prefix, so mark agent changes
+		if l.Agent != m.lastAgent {
+			result.Output = r.note("agent", "", l.Agent) + "\n" + result.Output
+		}
+	} else {
+		result.Output = prefixLines(result.Output, fmt.Sprintf("%s[%s]%s ", blue, l.Agent, reset))
+	}
+	m.lastAgent = l.Agent
+	return result
+}
+
+// renderConcat renders a line of concatenated files, announcing each file
+// before its first output
+func (m *agentMerger) renderConcat(l agentLine) ProcessedLine {
+	result := renderAt(l.Line, 0)
+	if result.Output == "" || l.Agent == m.lastAgent {
+		return result
+	}
+	m.lastAgent = l.Agent
+	if r := docRendererFor(outputFormat); r != nil {
+		result.Output = r.note("file", "", l.Agent) + "\n" + result.Output
+	} else if outputFormat == formatText {
+		result.Output = fmt.Sprintf("\n%s--- %s ---%s\n%s", dim, l.Agent, reset, result.Output)
+	}
+	return result
+}
+
+// prefixLines puts prefix in front of every non-empty line of text
+func prefixLines(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// streamAllAgents merges the latest session of every agent into one
+// chronological stream, following all of them at once unless follow is off
+func streamAllAgents(stateDir string, follow bool, tail int) {
+	sources := latestSessions(stateDir)
+	if len(sources) == 0 {
+		fmt.Fprintf(os.Stderr, "%sNo sessions found in %s%s\n", red, getAgentsDir(stateDir), reset)
+		os.Exit(1)
+	}
+
+	names := make([]string, len(sources))
+	for i, src := range sources {
+		names[i] = src.Agent
+	}
+	streamSources("all agents ("+strings.Join(names, ", ")+")", sources, follow, tail, false)
+}
+
+// streamFiles streams several session files as one, labeled by file name.
+// By default they're one session split across rotated files: each is
+// printed in turn and only the last is followed. With merge they're
+// interleaved by timestamp and all followed, like --all-agents.
+func streamFiles(paths []string, follow bool, tail int, merge bool) {
+	sources := make([]agentSession, len(paths))
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = path[strings.LastIndex(path, "/")+1:]
+		sources[i] = agentSession{Agent: names[i], Path: path}
+	}
+	streamSources(fmt.Sprintf("%d files (%s)", len(paths), strings.Join(names, ", ")), sources, follow, tail, !merge)
+}
+
+// streamSources prints sources as one stream with a single running total:
+// concatenated in order when concat is set, otherwise merged by timestamp.
+// Unless follow is off it then follows them, or only the last when
+// concatenating.
+func streamSources(title string, sources []agentSession, follow bool, tail int, concat bool) {
+	defer flushStdout()
+	printStreamHeader(title)
+
+	all := make([][]agentLine, len(sources))
+	offsets := make([]int64, len(sources))
+	for i, src := range sources {
+		lines, offset, err := readCompleteLines(src.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%sError reading %s: %v%s\n", red, src.Path, err, reset)
+			continue
+		}
+		offsets[i] = offset
+		for _, line := range lines {
+			at, _ := lineTime(line)
+			all[i] = append(all[i], agentLine{Agent: src.Agent, Line: line, At: at})
+		}
+	}
+
+	var merged []agentLine
+	if concat {
+		for i, lines := range all {
+			merged = append(merged, lines...)
+			if i < len(all)-1 {
+				// Earlier files were rotated out and won't grow
+				offsets[i] = -1
+			}
+		}
+	} else {
+		merged = mergeLines(all)
+	}
+	if follow && len(merged) > tail {
+		merged = merged[len(merged)-tail:]
+	}
+
+	var totals sessionTotals
+	merger := &agentMerger{concat: concat}
+	for _, l := range merged {
+		printProcessed(merger.render(l), &totals)
+		if outputCapped {
+			break
+		}
+	}
+
+	if !follow {
+		finishOutput()
+		totals.printSummary()
+		printStreamFooter()
+		return
+	}
+	if outputCapped {
+		return
+	}
+
+	// Follow every session at once; lines print in arrival order
+	lines := make(chan agentLine)
+	for i, src := range sources {
+		go followAgent(src, offsets[i], lines)
+	}
+
+	status := &statusLine{tty: isTerminal(os.Stdout)}
+	status.show(&totals)
+	status.exitOnInterrupt(&totals)
+	defer status.clear()
+	for {
+		select {
+		case l := <-lines:
+			status.update(merger.render(l), &totals)
+			if outputCapped {
+				return
+			}
+		case <-time.After(watchTimeout):
+			// Nothing new: print any run --dedupe is holding back
+			status.flushDedupe(&totals)
+			status.idle(&totals)
+		}
+	}
+}
+
+// followAgent sends each complete line appended to src's session after
+// offset, waiting on filesystem notifications between reads
+func followAgent(src agentSession, offset int64, lines chan<- agentLine) {
+	if offset < 0 {
+		return
+	}
+	file, err := os.Open(src.Path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sError opening %s: %v%s\n", red, src.Path, err, reset)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return
+	}
+
+	watcher, err := newFileWatcher(src.Path)
+	if err != nil {
+		watcher = nil
+	}
+	defer func() {
+		if watcher != nil {
+			watcher.Close()
+		}
+	}()
+
+	reader := newLineReader(file)
+	for {
+		line, err := reader.next()
+		if err == nil {
+			if line = strings.TrimSpace(line); line != "" {
+				lines <- agentLine{Agent: src.Agent, Line: line}
+			}
+			continue
+		}
+		if err != io.EOF {
+			return
+		}
+		waitForChange(watcher)
+	}
+}
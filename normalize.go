@@ -0,0 +1,148 @@
+package main
+
+import "strings"
+
+// Session formats accepted by --format-in: auto or a registered normalizer
+const (
+	inputAuto     = "auto"
+	inputOpenClaw = "openclaw"
+	inputInber    = "inber"
+)
+
+// Global input format; auto detects it line by line
+var inputFormat = inputAuto
+
+// normalizeEntry maps an entry of any registered format onto OpenClaw's
+// role, content, usage, and timestamp
+func normalizeEntry(entry *LogEntry) (string, interface{}, *Usage, interface{}) {
+	return normalizerFor(entry).Normalize(entry)
+}
+
+// Normalizer reads one session format, mapping its entries onto the role,
+// content, usage, and timestamp that the renderers work from
+type Normalizer interface {
+	// Detect reports whether an entry looks like this format, for
+	// --format-in auto
+	Detect(entry *LogEntry) bool
+	Normalize(entry *LogEntry) (role string, content interface{}, usage *Usage, ts interface{})
+}
+
+// namedNormalizer is a registered session format
+type namedNormalizer struct {
+	name string
+	Normalizer
+}
+
+// normalizers is the registry of session formats. Auto-detection tries them
+// in order and falls back to the first, so more specific formats go first.
+var normalizers = []namedNormalizer{
+	{inputOpenClaw, openClawNormalizer{}},
+	{inputInber, inberNormalizer{}},
+}
+
+// lookupNormalizer returns the registered format with the given name
+func lookupNormalizer(name string) (Normalizer, bool) {
+	for _, n := range normalizers {
+		if n.name == name {
+			return n.Normalizer, true
+		}
+	}
+	return nil, false
+}
+
+// normalizerNames lists the registered formats for usage and error messages
+func normalizerNames() string {
+	var names []string
+	for _, n := range normalizers {
+		names = append(names, n.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// normalizerFor picks the format of an entry: the one forced with
+// --format-in, or the first that detects it
+func normalizerFor(entry *LogEntry) Normalizer {
+	if n, ok := lookupNormalizer(inputFormat); ok {
+		return n
+	}
+	for _, n := range normalizers {
+		if n.Detect(entry) {
+			return n.Normalizer
+		}
+	}
+	return normalizers[0].Normalizer
+}
+
+// openClawNormalizer reads OpenClaw entries, which wrap each message in a
+// "message" object
+type openClawNormalizer struct{}
+
+// Detect matches any "message" object, even alongside top-level fields
+func (openClawNormalizer) Detect(entry *LogEntry) bool {
+	m := entry.Message
+	return m.Role != "" || m.Content != nil || m.Usage != nil || m.Model != ""
+}
+
+func (openClawNormalizer) Normalize(entry *LogEntry) (string, interface{}, *Usage, interface{}) {
+	var ts interface{}
+	if entry.TS != "" {
+		ts = entry.TS
+	} else if entry.Timestamp != nil {
+		ts = entry.Timestamp
+	}
+	usage := entry.Message.Usage
+	if entry.Message.Role != "" {
+		// Metadata entries may carry a running total; only messages cost
+		usage = withCost(usage, entry.Message.Cost, entry.Cost)
+	}
+	return entry.Message.Role, entry.Message.Content, usage, ts
+}
+
+// withCost fills in usage's cost from the first of costs that is set when
+// usage carries none itself, so a cost given in several places is counted
+// once and the most specific one wins. A cost without usage gets a usage
+// of its own so it still reaches the totals.
+func withCost(usage *Usage, costs ...*Cost) *Usage {
+	if usage != nil && usage.Cost != nil {
+		return usage
+	}
+	for _, cost := range costs {
+		if cost == nil {
+			continue
+		}
+		if usage == nil {
+			usage = &Usage{}
+		}
+		usage.Cost = cost
+		break
+	}
+	return usage
+}
+
+// inberNormalizer reads inber's flat entries, with the role and token
+// counts at the top level
+type inberNormalizer struct{}
+
+func (inberNormalizer) Detect(entry *LogEntry) bool {
+	return entry.Role != ""
+}
+
+func (inberNormalizer) Normalize(entry *LogEntry) (string, interface{}, *Usage, interface{}) {
+	// Build usage from inber fields. TotalTokens counts input and output,
+	// matching OpenClaw's totalTokens.
+	var usage *Usage
+	if entry.InTokens > 0 || entry.OutTokens > 0 {
+		usage = &Usage{
+			Input:       entry.InTokens,
+			Output:      entry.OutTokens,
+			TotalTokens: entry.InTokens + entry.OutTokens,
+		}
+		if entry.CostUSD > 0 {
+			usage.Cost = &Cost{
+				Total: entry.CostUSD,
+			}
+		}
+		usage = withCost(usage, entry.Cost)
+	}
+	return entry.Role, entry.Content, usage, entry.TS
+}
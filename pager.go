@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// defaultPager is used when $PAGER is unset; -R passes ANSI colors through
+const defaultPager = "less -R"
+
+// pagerCommand builds the pager process from $PAGER, falling back to
+// defaultPager
+func pagerCommand() *exec.Cmd {
+	fields := strings.Fields(os.Getenv("PAGER"))
+	if len(fields) == 0 {
+		fields = strings.Fields(defaultPager)
+	}
+	cmd := exec.Command(fields[0], fields[1:]...)
+	// A bare "less" from $PAGER would show escape codes literally
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=R")
+	}
+	return cmd
+}
+
+// paged runs render with stdout captured, then shows the output through the
+// pager if it is taller than the terminal, or prints it directly otherwise
+func paged(render func()) {
+	term := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		render()
+		return
+	}
+
+	captured := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		captured <- data
+	}()
+
+	os.Stdout = w
+	render()
+	w.Close()
+	os.Stdout = term
+	data := <-captured
+
+	height := terminalHeight(term)
+	if height == 0 || bytes.Count(data, []byte("\n")) < height {
+		term.Write(data)
+		return
+	}
+
+	cmd := pagerCommand()
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = term
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		// Pager missing or failed to start: don't lose the output
+		if _, ok := err.(*exec.ExitError); !ok {
+			term.Write(data)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// price is what a model charges, in dollars per 1k tokens
+type price struct {
+	Input      float64
+	Output     float64
+	CacheRead  float64
+	CacheWrite float64
+}
+
+// modelPrice is a --price rule: models whose name contains pattern
+// (case-insensitive) are charged at price; "*" matches every model
+type modelPrice struct {
+	pattern string
+	price
+}
+
+// Global --price rules, checked in order; the first match wins
+var prices []modelPrice
+
+// parsePrice parses a --price rule: MODEL=IN,OUT[,CACHE_READ[,CACHE_WRITE]]
+// in dollars per 1k tokens. Cache rates left out are free.
+func parsePrice(spec string) (modelPrice, error) {
+	pattern, rates, ok := strings.Cut(spec, "=")
+	if !ok || strings.TrimSpace(pattern) == "" {
+		return modelPrice{}, fmt.Errorf("expected MODEL=IN,OUT[,CACHE_READ,CACHE_WRITE], got %q", spec)
+	}
+	fields := strings.Split(rates, ",")
+	if len(fields) < 2 || len(fields) > 4 {
+		return modelPrice{}, fmt.Errorf("expected 2 to 4 rates for %s, got %d", pattern, len(fields))
+	}
+	values := make([]float64, 4)
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || v < 0 {
+			return modelPrice{}, fmt.Errorf("invalid rate %q for %s", field, pattern)
+		}
+		values[i] = v
+	}
+	return modelPrice{
+		pattern: strings.ToLower(strings.TrimSpace(pattern)),
+		price:   price{Input: values[0], Output: values[1], CacheRead: values[2], CacheWrite: values[3]},
+	}, nil
+}
+
+// priceFor returns the first --price rule matching model
+func priceFor(model string) (price, bool) {
+	model = strings.ToLower(model)
+	for _, p := range prices {
+		if p.pattern == "*" || model != "" && strings.Contains(model, p.pattern) {
+			return p.price, true
+		}
+	}
+	return price{}, false
+}
+
+// estimateCost fills in a cost from --price for usage that was logged
+// without one, marking it estimated. Reported costs are left alone.
+func estimateCost(usage *Usage, model string) {
+	if usage == nil || usage.Cost != nil {
+		return
+	}
+	p, ok := priceFor(model)
+	if !ok {
+		return
+	}
+	cost := &Cost{
+		Input:      float64(usage.Input) * p.Input / 1000,
+		Output:     float64(usage.Output) * p.Output / 1000,
+		CacheRead:  float64(usage.CacheRead) * p.CacheRead / 1000,
+		CacheWrite: float64(usage.CacheWrite) * p.CacheWrite / 1000,
+		Estimated:  true,
+	}
+	cost.Total = cost.Input + cost.Output + cost.CacheRead + cost.CacheWrite
+	usage.Cost = cost
+}
+
+// approxCost renders a cost, with a "~" in front when any of it was
+// estimated rather than reported
+func approxCost(cost float64, estimated bool) string {
+	if estimated {
+		return "~" + formatCost(cost)
+	}
+	return formatCost(cost)
+}
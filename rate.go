@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Output token samples kept for the follow-mode rate meter, and how far back
+// from the newest one they count
+const (
+	rateSamples = 32
+	rateWindow  = time.Minute
+)
+
+// rateSample is the output tokens of one message and when it was written
+type rateSample struct {
+	at     time.Time
+	tokens int
+}
+
+// rateMeter is a ring buffer of recent output token samples, used to show
+// generation throughput in the status line
+type rateMeter struct {
+	samples [rateSamples]rateSample
+	next    int
+	count   int
+}
+
+// add records a message's output tokens
+func (m *rateMeter) add(at time.Time, tokens int) {
+	m.samples[m.next] = rateSample{at: at, tokens: tokens}
+	m.next = (m.next + 1) % rateSamples
+	m.count = min(m.count+1, rateSamples)
+}
+
+// rate returns output tokens per second since the last sample more than
+// rateWindow before the newest one, or since the oldest sample. That first
+// sample only marks the start, since its tokens were generated before it, so
+// a long quiet spell shows up as a low rate. ok is false until there are two
+// samples at different times.
+func (m *rateMeter) rate() (perSecond float64, ok bool) {
+	if m.count < 2 {
+		return 0, false
+	}
+	newest := m.samples[(m.next-1+rateSamples)%rateSamples]
+	oldest, tokens := newest, 0
+	for i := 1; i <= m.count; i++ {
+		s := m.samples[(m.next-i+rateSamples)%rateSamples]
+		tokens += s.tokens
+		oldest = s
+		if newest.at.Sub(s.at) > rateWindow {
+			break
+		}
+	}
+	span := newest.at.Sub(oldest.at)
+	if span <= 0 {
+		return 0, false
+	}
+	return float64(tokens-oldest.tokens) / span.Seconds(), true
+}
+
+// rateText renders the rate for the status line, like " | 42 tok/s"
+func (m *rateMeter) rateText() string {
+	perSecond, ok := m.rate()
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" | %.0f tok/s", perSecond)
+}
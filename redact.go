@@ -0,0 +1,105 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Placeholder for redacted secrets
+const redacted = "[redacted]"
+
+// redactRule rewrites matches of pattern to replace, a regexp template.
+// When secret is set, a match is only redacted if it looks like a key: long
+// runs of letters alone (identifiers, prose) are left alone.
+type redactRule struct {
+	pattern *regexp.Regexp
+	replace string
+	secret  bool
+}
+
+// Global redaction rules from --anonymize and --redact-pattern, applied to
+// rendered output
+var redactRules []redactRule
+
+// anonymizeRules scrub home directories and things that look like
+// credentials
+var anonymizeRules = []redactRule{
+	// Home directories, keeping the rest of the path
+	{pattern: regexp.MustCompile(`(?:/home|/Users)/[^/\s"':]+`), replace: "~"},
+	{pattern: regexp.MustCompile(`[A-Za-z]:\\Users\\[^\\\s"':]+`), replace: "~"},
+	// Credentials named as such: "Bearer ...", "api_key=...", "password: ...".
+	// The value can't end in closing punctuation, so "(token=abc123)." keeps
+	// its ")." after the placeholder.
+	{pattern: regexp.MustCompile(`(?i)(bearer\s+)[^\s"',]*[^\s"',)\]}.;]`), replace: "${1}" + redacted},
+	{pattern: regexp.MustCompile(`(?i)((?:api[_-]?key|access[_-]?token|auth[_-]?token|token|secret|password)"?\s*[=:]\s*"?)[^\s"',]{5,}[^\s"',)\]}.;]`), replace: "${1}" + redacted},
+	// Well-known key formats
+	{pattern: regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_\-]{16,}`), replace: redacted},
+	{pattern: regexp.MustCompile(`\b(?:ghp|gho|ghs|ghu|github_pat)_[A-Za-z0-9_]{20,}`), replace: redacted},
+	{pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}`), replace: redacted},
+	{pattern: regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), replace: redacted},
+	// Long hex or base64 runs
+	{pattern: regexp.MustCompile(`\b[0-9a-fA-F]{32,}\b`), replace: redacted, secret: true},
+	{pattern: regexp.MustCompile(`[A-Za-z0-9+_\-]{40,}={0,2}`), replace: redacted, secret: true},
+}
+
+// setRedactRules installs the built-in anonymize rules if requested,
+// followed by a rule for each custom pattern
+func setRedactRules(anonymize bool, patterns []string) error {
+	var rules []redactRule
+	if anonymize {
+		rules = append(rules, anonymizeRules...)
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, redactRule{pattern: re, replace: redacted})
+	}
+	redactRules = rules
+	return nil
+}
+
+// ansiPattern matches the color escapes in rendered output
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// redactOutput applies the redaction rules to rendered output, one colored
+// span at a time so escape codes are never rewritten
+func redactOutput(output string) string {
+	if len(redactRules) == 0 {
+		return output
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range ansiPattern.FindAllStringIndex(output, -1) {
+		b.WriteString(redact(output[last:loc[0]]))
+		b.WriteString(output[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(redact(output[last:]))
+	return b.String()
+}
+
+// redact applies the redaction rules to text
+func redact(text string) string {
+	for _, rule := range redactRules {
+		if !rule.secret {
+			text = rule.pattern.ReplaceAllString(text, rule.replace)
+			continue
+		}
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if !looksLikeKey(match) {
+				return match
+			}
+			return rule.replace
+		})
+	}
+	return text
+}
+
+// looksLikeKey reports whether a long token mixes letters and digits, as
+// generated keys do
+func looksLikeKey(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0 && strings.IndexFunc(s, unicode.IsLetter) >= 0
+}
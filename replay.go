@@ -0,0 +1,34 @@
+package main
+
+import "time"
+
+// Longest pause --replay makes between two messages, before --speed scaling
+const replayMaxDelay = 5 * time.Second
+
+// replayer paces a dump by the original timestamps for --replay
+type replayer struct {
+	speed float64
+	last  time.Time
+}
+
+// Global replayer; nil unless --replay is set
+var replay *replayer
+
+// delay returns how long to wait before printing result: the time since
+// the previous printed message, capped at replayMaxDelay and divided by
+// speed. Skipped and untimestamped lines print immediately.
+func (r *replayer) delay(result ProcessedLine) time.Duration {
+	if result.Output == "" || !result.HasTimestamp {
+		return 0
+	}
+	last := r.last
+	r.last = result.Timestamp
+	if last.IsZero() {
+		return 0
+	}
+	gap := min(result.Timestamp.Sub(last), replayMaxDelay)
+	if gap <= 0 || r.speed <= 0 {
+		return 0
+	}
+	return time.Duration(float64(gap) / r.speed)
+}
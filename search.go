@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Characters of context kept on each side of a match in a search snippet
+const snippetContext = 40
+
+// searchMatch is a session line whose text contains the search query, or a
+// message around one shown for --context
+type searchMatch struct {
+	Line    int
+	Role    string
+	Time    time.Time
+	Snippet string
+	// Context marks a surrounding message rather than a match; Gap marks
+	// the first line of a group not contiguous with the previous one
+	Context bool
+	Gap     bool
+}
+
+// searchableText returns the text of an entry worth searching: message
+// text plus the output of any tool results it carries
+func searchableText(content interface{}) string {
+	parts := []string{extractText(content)}
+	for _, block := range contentBlocks(content, "toolResult") {
+		parts = append(parts, toolResultText(block))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// searchReader returns the lines of r whose text matches query, each with
+// up to context messages before and after it, like grep -C
+func searchReader(r io.Reader, query *regexp.Regexp, context int) ([]searchMatch, error) {
+	var matches []searchMatch
+	// Messages are numbered so groups can tell whether they're contiguous
+	var before []searchMatch
+	var beforeIndex []int
+	lastShown, after := -1, 0
+	show := func(m searchMatch, index int) {
+		m.Gap = lastShown >= 0 && index > lastShown+1
+		matches = append(matches, m)
+		lastShown = index
+	}
+
+	scanner := newLineScanner(r)
+	index := 0
+	for n := 1; scanner.Scan(); n++ {
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		role, content, _, tsValue := normalizeEntry(&entry)
+		text := strings.Join(strings.Fields(searchableText(content)), " ")
+		if role == "" || text == "" {
+			continue
+		}
+		index++
+		at, _ := parseTimestamp(tsValue)
+		m := searchMatch{Line: n, Role: role, Time: at}
+
+		if loc := query.FindStringIndex(text); loc != nil {
+			for i, b := range before {
+				show(b, beforeIndex[i])
+			}
+			before, beforeIndex = before[:0], beforeIndex[:0]
+			m.Snippet = snippet(text, loc[0], loc[1])
+			show(m, index)
+			after = context
+			continue
+		}
+		if context == 0 {
+			continue
+		}
+		m.Context = true
+		m.Snippet = truncate(text, 2*snippetContext)
+		if after > 0 {
+			show(m, index)
+			after--
+			continue
+		}
+		before, beforeIndex = append(before, m), append(beforeIndex, index)
+		if len(before) > context {
+			before, beforeIndex = before[1:], beforeIndex[1:]
+		}
+	}
+	return matches, scanner.Err()
+}
+
+// snippet returns the text around text[start:end] on one line, with the
+// match highlighted and cut edges marked with "…"
+func snippet(text string, start, end int) string {
+	from := max(start-snippetContext, 0)
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+	to := min(end+snippetContext, len(text))
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
+	}
+	prefix, suffix := "", ""
+	if from > 0 {
+		prefix = "…"
+	}
+	if to < len(text) {
+		suffix = "…"
+	}
+	return fmt.Sprintf("%s%s%s%s%s%s%s%s", prefix, text[from:start], yellow, bold, text[start:end], reset, text[end:to], suffix)
+}
+
+// searchSessions scans every session of the given agents for query
+// (case-insensitive) and prints each matching line with its session path,
+// line number, timestamp, and a snippet, plus context messages around it.
+// It returns the number of matches.
+func searchSessions(stateDir string, agents []string, query string, context int) int {
+	pattern := regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+
+	fmt.Printf("%sSearch: %q (%s)%s\n", yellow, query, strings.Join(agents, ", "), reset)
+	fmt.Printf("%s%s%s\n", dim, strings.Repeat("─", 60), reset)
+
+	total, sessionCount := 0, 0
+	for _, agent := range agents {
+		for _, session := range getSessions(stateDir, agent) {
+			file, err := openSession(session.Path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%sError opening %s: %v%s\n", red, session.Path, err, reset)
+				continue
+			}
+			matches, err := searchReader(file, pattern, context)
+			file.Close()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%sError reading %s: %v%s\n", red, session.Path, err, reset)
+			}
+			found := 0
+			for _, m := range matches {
+				if !m.Context {
+					found++
+				}
+			}
+			if found == 0 {
+				continue
+			}
+
+			sessionCount++
+			total += found
+			fmt.Printf("\n%s%s%s\n", bold, session.Path, reset)
+			for _, m := range matches {
+				if m.Gap {
+					fmt.Printf("  %s--%s\n", dim, reset)
+				}
+				when := ""
+				if !m.Time.IsZero() {
+					t := m.Time
+					if timeZone != nil {
+						t = t.In(timeZone)
+					}
+					when = t.Format("2006-01-02 15:04") + "  "
+				}
+				if m.Context {
+					fmt.Printf("  %s%5d  %s%-9s %s%s\n", dim, m.Line, when, m.Role, m.Snippet, reset)
+					continue
+				}
+				fmt.Printf("  %s%5d  %s%-9s%s %s\n", dim, m.Line, when, m.Role, reset, m.Snippet)
+			}
+		}
+	}
+
+	if total == 0 {
+		fmt.Printf("\n%sNo matches%s\n", dim, reset)
+	} else {
+		word := "matches"
+		if total == 1 {
+			word = "match"
+		}
+		fmt.Printf("\n%s%d %s in %d %s%s\n", dim, total, word, sessionCount, plural(sessionCount, "session"), reset)
+	}
+	return total
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Global flag: end dumps with a sparkline of output tokens per message
+var sparklineMode bool
+
+// sparkBlocks are the sparkline's levels, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as one block per value, scaled to the largest.
+// Longer series are bucketed to fit width, each bucket showing its sum so a
+// busy stretch stands out however it's sampled.
+func sparkline(values []int, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	buckets := values
+	if len(values) > width {
+		buckets = make([]int, width)
+		for i, v := range values {
+			buckets[i*width/len(values)] += v
+		}
+	}
+	peak := 0
+	for _, v := range buckets {
+		peak = max(peak, v)
+	}
+
+	var b strings.Builder
+	for _, v := range buckets {
+		level := 0
+		if peak > 0 {
+			level = v * (len(sparkBlocks) - 1) / peak
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return b.String()
+}
+
+// printSparkline prints the output tokens of each assistant message as a
+// sparkline, with the largest single message, fitting the whole line in the
+// wrap width or the summary's rule
+func (t *sessionTotals) printSparkline() {
+	if len(t.OutputSeries) == 0 {
+		return
+	}
+	label := "Output: "
+	width := 60
+	if wrapWidth > 0 {
+		width = wrapWidth
+	}
+	peak := 0
+	for _, v := range t.OutputSeries {
+		peak = max(peak, v)
+	}
+	// The peak is per message even when bars sum several messages
+	n := len(t.OutputSeries)
+	suffix := fmt.Sprintf(" (%d %s, max %s per message)", n, plural(n, "message"), formatNumber(peak))
+	line := sparkline(t.OutputSeries, max(width-len(label)-len(suffix), 10))
+	fmt.Fprintf(stdout(), "%s%s%s%s%s%s%s%s\n", dim, label, reset, green, line, dim, suffix, reset)
+}
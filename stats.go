@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// sessionStats holds aggregate counts for a session, collected without
+// rendering any messages. Totals also tracks the first and last timestamps,
+// as it does for the dump summary.
+type sessionStats struct {
+	Roles  map[string]int
+	Tools  map[string]int
+	Totals sessionTotals
+}
+
+func newSessionStats() *sessionStats {
+	return &sessionStats{
+		Roles: make(map[string]int),
+		Tools: make(map[string]int),
+	}
+}
+
+// addLine folds a single JSONL line into the stats
+func (s *sessionStats) addLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	var entry LogEntry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return
+	}
+	role, content, usage, tsValue := normalizeEntry(&entry)
+	if role == "" {
+		return
+	}
+
+	s.Roles[role]++
+	if role == "tool_call" {
+		name := entry.ToolName
+		if name == "" {
+			name = "?"
+		}
+		s.Tools[name]++
+	}
+	for _, block := range contentBlocks(content, "toolCall") {
+		s.Tools[toolCallName(block)]++
+	}
+
+	s.Totals.add(usage)
+	s.Totals.addModel(entryModel(&entry), usage)
+
+	if t, ok := parseTimestamp(tsValue); ok {
+		s.Totals.see(t)
+	}
+}
+
+// collectStats scans every line of r into a sessionStats
+func collectStats(r io.Reader) (*sessionStats, error) {
+	stats := newSessionStats()
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		stats.addLine(scanner.Text())
+	}
+	return stats, scanner.Err()
+}
+
+// sortedCounts returns the keys of counts ordered by count descending, then name
+func sortedCounts(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func formatCounts(counts map[string]int) string {
+	var parts []string
+	for _, k := range sortedCounts(counts) {
+		parts = append(parts, fmt.Sprintf("%s %d", k, counts[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (s *sessionStats) print(name string) {
+	total := 0
+	for _, n := range s.Roles {
+		total += n
+	}
+	toolTotal := 0
+	for _, n := range s.Tools {
+		toolTotal += n
+	}
+
+	fmt.Fprintf(stdout(), "%sStats: %s%s\n", yellow, name, reset)
+	fmt.Fprintf(stdout(), "%s%s%s\n\n", dim, strings.Repeat("─", 60), reset)
+	fmt.Fprintf(stdout(), "  %sMessages:%s   %d", bold, reset, total)
+	if total > 0 {
+		fmt.Fprintf(stdout(), "  %s(%s)%s", dim, formatCounts(s.Roles), reset)
+	}
+	fmt.Fprintln(stdout())
+	fmt.Fprintf(stdout(), "  %sTool calls:%s %d", bold, reset, toolTotal)
+	if toolTotal > 0 {
+		fmt.Fprintf(stdout(), "  %s(%s)%s", dim, formatCounts(s.Tools), reset)
+	}
+	fmt.Fprintln(stdout())
+	fmt.Fprintf(stdout(), "  %sTokens:%s     %s\n", bold, reset, &s.Totals)
+	if duration, ok := s.Totals.span(); ok {
+		first, last := s.Totals.First, s.Totals.Last
+		if timeZone != nil {
+			first, last = first.In(timeZone), last.In(timeZone)
+		}
+		fmt.Fprintf(stdout(), "  %sDuration:%s   %s  %s(%s, %s → %s)%s\n", bold, reset, formatDuration(duration),
+			dim, isoDuration(duration), first.Format("2006-01-02 15:04:05"), last.Format("2006-01-02 15:04:05"), reset)
+	}
+	s.printToolChart()
+	if byModelMode {
+		s.Totals.printModelBreakdown()
+	}
+}
+
+// barBlocks are the eighths used for the fractional end of a bar
+var barBlocks = []rune("▏▎▍▌▋▊▉█")
+
+// bar draws n out of peak as a bar up to width cells long, at eighth-cell
+// resolution; any nonzero n shows at least a sliver
+func bar(n, peak, width int) string {
+	if n <= 0 || peak <= 0 || width <= 0 {
+		return ""
+	}
+	eighths := max(n*width*8/peak, 1)
+	s := strings.Repeat("█", eighths/8)
+	if rest := eighths % 8; rest > 0 {
+		s += string(barBlocks[rest-1])
+	}
+	return s
+}
+
+// printToolChart prints a bar per tool, most called first, scaled so the
+// longest fits the wrap width (or the stats rule)
+func (s *sessionStats) printToolChart() {
+	if len(s.Tools) == 0 {
+		return
+	}
+	tools := sortedCounts(s.Tools)
+	peak := s.Tools[tools[0]]
+	nameWidth := 0
+	for _, name := range tools {
+		nameWidth = max(nameWidth, len(name))
+	}
+	width := 60
+	if wrapWidth > 0 {
+		width = wrapWidth
+	}
+	// "  name  bar count"
+	barWidth := max(width-nameWidth-len(fmt.Sprint(peak))-5, 10)
+
+	fmt.Fprintf(stdout(), "\n%sTools:%s\n", bold, reset)
+	for _, name := range tools {
+		n := s.Tools[name]
+		fmt.Fprintf(stdout(), "  %-*s  %s%s%s %s%d%s\n", nameWidth, name, roleColor("tool_call"), bar(n, peak, barWidth), reset, dim, n, reset)
+	}
+}
+
+// showStats prints aggregate stats for a session file
+func showStats(path string) {
+	file, err := openSession(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sError opening file: %v%s\n", red, err, reset)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	stats, err := collectStats(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%sError reading file: %v%s\n", red, err, reset)
+		os.Exit(1)
+	}
+	stats.print(path[strings.LastIndex(path, "/")+1:])
+	flushStdout()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Columns of the --format csv and tsv usage tables
+var usageColumns = []string{"timestamp", "model", "input", "output", "cacheRead", "cacheWrite", "totalTokens", "cost"}
+
+// isTableFormat reports whether format is one of the usage table formats
+func isTableFormat(format string) bool {
+	return format == formatCSV || format == formatTSV
+}
+
+// tableRow renders fields as one CSV or TSV row, without the trailing newline
+func tableRow(fields []string) string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if outputFormat == formatTSV {
+		w.Comma = '\t'
+	}
+	w.Write(fields)
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// processUsageLine renders a JSONL line as a usage table row. Only entries
+// carrying usage produce a row; the model and role filters still apply.
+func processUsageLine(line string) (result ProcessedLine) {
+	e, done, ok := parseLine(line, nil)
+	if !ok {
+		return done
+	}
+	// Rows carry full timestamps rather than the clock, so --dedupe compares
+	// them whole
+	e.clock = ""
+	defer e.annotate(&result)
+	usage, model := e.usage, entryModel(&e.entry)
+	if usage == nil {
+		return ProcessedLine{}
+	}
+
+	ts := ""
+	if e.hasTime {
+		tsTime := e.time
+		if timeZone != nil {
+			tsTime = tsTime.In(timeZone)
+		}
+		ts = tsTime.Format(time.RFC3339)
+	}
+	cost := 0.0
+	if usage.Cost != nil {
+		cost = usage.Cost.Total
+	}
+	return ProcessedLine{
+		Output: tableRow([]string{
+			ts,
+			model,
+			strconv.Itoa(usage.Input),
+			strconv.Itoa(usage.Output),
+			strconv.Itoa(usage.CacheRead),
+			strconv.Itoa(usage.CacheWrite),
+			strconv.Itoa(usage.TotalTokens),
+			strconv.FormatFloat(cost, 'f', -1, 64),
+		}),
+		Usage: usage,
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !linux && !darwin
+
+package main
+
+import (
+	"os"
+	"strconv"
+)
+
+// terminalWidth falls back to $COLUMNS where the window size can't be queried
+func terminalWidth(f *os.File) int {
+	if !isTerminal(f) {
+		return 0
+	}
+	cols, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	return cols
+}
+
+// terminalHeight falls back to $LINES where the window size can't be queried
+func terminalHeight(f *os.File) int {
+	if !isTerminal(f) {
+		return 0
+	}
+	rows, _ := strconv.Atoi(os.Getenv("LINES"))
+	return rows
+}
@@ -0,0 +1,36 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// winsize queries the window size of the terminal attached to f, returning
+// zeros if f is not a terminal
+func winsize(f *os.File) (cols, rows int) {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0, 0
+	}
+	return int(ws.Col), int(ws.Row)
+}
+
+// terminalWidth returns the column count of the terminal attached to f, or
+// 0 if f is not a terminal
+func terminalWidth(f *os.File) int {
+	cols, _ := winsize(f)
+	return cols
+}
+
+// terminalHeight returns the row count of the terminal attached to f, or
+// 0 if f is not a terminal
+func terminalHeight(f *os.File) int {
+	_, rows := winsize(f)
+	return rows
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Theme used when neither --theme nor the config file picks one
+const defaultTheme = "dark"
+
+// theme is a set of ANSI codes for the color variables
+type theme struct {
+	cyan, green, yellow, red, dim, bold, reset, magenta, blue string
+}
+
+// Color themes selectable with --theme. dark is the default; light swaps in
+// darker 256-color shades that stay readable on a white background, and mono
+// keeps only bold and dim.
+var themes = map[string]theme{
+	"dark": {
+		cyan:    "\033[36m",
+		green:   "\033[32m",
+		yellow:  "\033[33m",
+		red:     "\033[31m",
+		dim:     "\033[2m",
+		bold:    "\033[1m",
+		reset:   "\033[0m",
+		magenta: "\033[35m",
+		blue:    "\033[34m",
+	},
+	"light": {
+		cyan:    "\033[38;5;30m",
+		green:   "\033[38;5;28m",
+		yellow:  "\033[38;5;130m",
+		red:     "\033[38;5;160m",
+		dim:     "\033[38;5;242m",
+		bold:    "\033[1m",
+		reset:   "\033[0m",
+		magenta: "\033[38;5;90m",
+		blue:    "\033[38;5;25m",
+	},
+	"mono": {
+		dim:   "\033[2m",
+		bold:  "\033[1m",
+		reset: "\033[0m",
+	},
+}
+
+// themeNames lists the available themes for error messages
+func themeNames() string {
+	var names []string
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// applyTheme sets the color variables from the named theme
+func applyTheme(name string) error {
+	t, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q (expected %s)", name, themeNames())
+	}
+	cyan, green, yellow, red, dim, bold, reset, magenta, blue = t.cyan, t.green, t.yellow, t.red, t.dim, t.bold, t.reset, t.magenta, t.blue
+	return nil
+}
+
+// Roles whose color can be overridden with --color-<role> or the config
+// file's "colors" section
+var colorRoles = []string{"user", "assistant", "thinking", "tool", "system"}
+
+// Global per-role color overrides, as ANSI codes keyed by colorRoles
+var roleColors map[string]string
+
+// colorNames maps the color names accepted in overrides to ANSI codes
+var colorNames = map[string]string{
+	"black":   "\033[30m",
+	"red":     "\033[31m",
+	"green":   "\033[32m",
+	"yellow":  "\033[33m",
+	"blue":    "\033[34m",
+	"magenta": "\033[35m",
+	"cyan":    "\033[36m",
+	"white":   "\033[37m",
+	"gray":    "\033[90m",
+	"grey":    "\033[90m",
+}
+
+// parseColor turns a color name, "bright-<name>", or a 256-color index into
+// an ANSI code
+func parseColor(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if code, ok := colorNames[value]; ok {
+		return code, nil
+	}
+	if name, ok := strings.CutPrefix(value, "bright-"); ok {
+		if code, ok := colorNames[name]; ok {
+			// \033[3Xm becomes \033[9Xm
+			return "\033[9" + code[3:], nil
+		}
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 255 {
+		return fmt.Sprintf("\033[38;5;%dm", n), nil
+	}
+	return "", fmt.Errorf("unknown color %q (expected a name like blue or bright-cyan, or 0-255)", value)
+}
+
+// setRoleColor records an override for role
+func setRoleColor(role, value string) error {
+	if !slices.Contains(colorRoles, role) {
+		return fmt.Errorf("unknown role %q (expected %s)", role, strings.Join(colorRoles, ", "))
+	}
+	code, err := parseColor(value)
+	if err != nil {
+		return err
+	}
+	if roleColors == nil {
+		roleColors = make(map[string]string)
+	}
+	roleColors[role] = code
+	return nil
+}
+
+// roleColor returns the color for a role's header and label, honoring any
+// override for it
+func roleColor(role string) string {
+	key := role
+	switch role {
+	case "tool_call", "tool_result":
+		key = "tool"
+	case "request":
+		key = "system"
+	}
+	if code, ok := roleColors[key]; ok {
+		return code
+	}
+	switch role {
+	case "user":
+		return cyan
+	case "assistant":
+		return green
+	case "thinking":
+		return yellow
+	case "system", "request":
+		return blue
+	case "tool_call":
+		return magenta
+	}
+	return dim
+}
@@ -0,0 +1,46 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// fileWatcher blocks until a watched file changes, backed by inotify
+type fileWatcher struct {
+	file *os.File
+	buf  []byte
+}
+
+func newFileWatcher(path string) (*fileWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	mask := uint32(syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE | syscall.IN_MOVE_SELF | syscall.IN_DELETE_SELF)
+	if _, err := syscall.InotifyAddWatch(fd, path, mask); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	// A non-blocking fd is registered with the runtime poller, so read
+	// deadlines work and a wait never pins an OS thread
+	return &fileWatcher{file: os.NewFile(uintptr(fd), "inotify"), buf: make([]byte, 4096)}, nil
+}
+
+// wait blocks until the file changes or the timeout elapses. A timeout is
+// not an error; any other read failure is returned so callers can fall back
+// to polling.
+func (w *fileWatcher) wait(timeout time.Duration) error {
+	w.file.SetReadDeadline(time.Now().Add(timeout))
+	_, err := w.file.Read(w.buf)
+	if err != nil && !os.IsTimeout(err) {
+		return err
+	}
+	return nil
+}
+
+func (w *fileWatcher) Close() error {
+	return w.file.Close()
+}
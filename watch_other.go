@@ -0,0 +1,24 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// fileWatcher is unavailable on this platform; follow mode polls instead
+type fileWatcher struct{}
+
+func newFileWatcher(path string) (*fileWatcher, error) {
+	return nil, errors.New("file notifications not supported on this platform")
+}
+
+func (w *fileWatcher) wait(timeout time.Duration) error {
+	time.Sleep(timeout)
+	return nil
+}
+
+func (w *fileWatcher) Close() error {
+	return nil
+}